@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/client"
+)
+
+// runCompare реализует режим "compare": сравнивает отчёты с первым (базовым)
+// и возвращает код выхода — 1, если найдена регрессия сверх порогов
+func runCompare(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	var th client.Thresholds
+	fs.Float64Var(&th.RPS, "rps-threshold", 5, "Allowed RPS drop, percent")
+	fs.Float64Var(&th.Latency, "latency-threshold", 10, "Allowed latency increase (mean and percentiles), percent")
+	fs.Float64Var(&th.ErrorRate, "error-rate-threshold", 0.5, "Allowed error rate increase, percentage points")
+	fs.Float64Var(&th.Alpha, "alpha", 0.05, "Significance level of the Mann-Whitney U test")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s compare [flags] base.json new.json [more.json...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := applyEnv(fs); err != nil {
+		log.Printf("Ошибка параметров: %v", err)
+		return 2
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return 2
+	}
+
+	base, err := client.ReadReport(fs.Arg(0))
+	if err != nil {
+		log.Printf("Ошибка чтения отчёта: %v", err)
+		return 2
+	}
+
+	regressed := false
+	for _, path := range fs.Args()[1:] {
+		cur, err := client.ReadReport(path)
+		if err != nil {
+			log.Printf("Ошибка чтения отчёта: %v", err)
+			return 2
+		}
+		cmps := client.Compare(base, cur, th)
+		if err := client.WriteComparison(os.Stdout, fs.Arg(0)+" → "+path, cmps); err != nil {
+			log.Printf("Ошибка вывода: %v", err)
+			return 2
+		}
+		for _, c := range cmps {
+			regressed = regressed || c.Regressed()
+		}
+	}
+
+	if regressed {
+		log.Println("Обнаружена регрессия производительности")
+		return 1
+	}
+	return 0
+}
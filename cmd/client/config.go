@@ -0,0 +1,279 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/client"
+)
+
+// envPrefix — префикс переменных окружения, переопределяющих флаги:
+// флаг -start-delay задаётся переменной BENCH_START_DELAY и т.д.
+// Явно указанный флаг командной строки важнее переменной окружения.
+const envPrefix = "BENCH_"
+
+// config — настройки клиента бенчмарка из флагов и окружения
+type config struct {
+	debug   bool
+	verbose bool
+	logFile string
+
+	target   string
+	insecure bool
+	caFile   string
+	certFile string
+	keyFile  string
+
+	plan         string
+	rpcs         []string
+	mix          []client.MixEntry
+	count        int
+	load         client.LoadOptions
+	pushMsg      string
+	startDelay   time.Duration
+	format       string
+	out          string
+	slos         sloList
+	sweepSizes   []int
+	sweepSide    string
+	compressions []string // больше одного алгоритма или вида payload — сравнение сжатия
+	fills        []string
+
+	coordinator string // адрес координатора распределённого прогона
+	agents      int
+	agentWait   time.Duration
+	join        string // адрес координатора, к которому подключается агент
+	agentName   string
+
+	maxPayload int // наибольший payload этапов плана, под него поднимаются лимиты сообщений gRPC
+
+	metricsPort    string // адрес endpoint'а /metrics клиента
+	openMetricsOut string // файл итогов в формате OpenMetrics
+
+	traceEndpoint string // коллектор Jaeger; пусто — без трассировки
+	traceSample   float64
+
+	call     string // полное имя произвольного метода вместо -rpcs
+	protoset string
+	data     string // шаблон запроса -call или @файл
+}
+
+// setupFlags парсит флаги командной строки и переменные окружения
+func setupFlags() (*config, error) {
+	cfg := &config{}
+	var rpcs, mix, scenario, profile, rpcTimeouts, sweepSizes, compressions, fills string
+
+	flag.BoolVar(&cfg.debug, "debug", false, "Enable debug logs")
+	flag.BoolVar(&cfg.verbose, "verbose", false, "Enable verbose logs")
+	flag.StringVar(&cfg.logFile, "log-file", "../../logs/client.log", "Log file path")
+
+	flag.StringVar(&cfg.target, "target", "localhost:50051", "gRPC server address")
+	flag.BoolVar(&cfg.insecure, "insecure", false, "Connect without TLS")
+	flag.StringVar(&cfg.caFile, "ca", "certs/ca.crt", "CA certificate (empty: system roots)")
+	flag.StringVar(&cfg.certFile, "cert", "certs/client.crt", "Client certificate for mTLS (empty: no client auth)")
+	flag.StringVar(&cfg.keyFile, "key", "certs/client.key", "Client private key for mTLS")
+
+	flag.StringVar(&cfg.plan, "plan", "", "Benchmark plan file (YAML or JSON); replaces -rpcs, flags give stage defaults")
+	flag.StringVar(&rpcs, "rpcs", strings.Join(client.RPCs, ","), "Comma-separated RPCs to run: "+strings.Join(client.RPCs, ", "))
+	flag.StringVar(&cfg.call, "call", "", "Benchmark an arbitrary method instead of -rpcs, e.g. helloworld.Greeter/SayHello")
+	flag.StringVar(&cfg.protoset, "protoset", "", "FileDescriptorSet describing -call (protoc --include_imports --descriptor_set_out); empty: server reflection")
+	flag.StringVar(&cfg.data, "data", "{}", "JSON request template for -call or @file; supports {{.Seq}}, {{.Worker}}, {{randInt 1 9}}, {{uuid}}")
+	flag.StringVar(&mix, "mix", "", "Weighted RPC mix in one run instead of -rpcs, e.g. Ping=80,StreamPing=15,AggregatePing=5")
+	flag.IntVar(&cfg.count, "count", 1, "Repeat every benchmark N times for significance testing in compare")
+	flag.IntVar(&cfg.load.Requests, "requests", 1000, "Number of measured requests per RPC")
+	flag.DurationVar(&cfg.load.Duration, "duration", 0, "Measured phase duration per RPC (overrides -requests)")
+	flag.DurationVar(&cfg.load.WarmUp, "warmup", 0, "Warm-up duration, samples are discarded")
+	flag.DurationVar(&cfg.load.CoolDown, "cooldown", 0, "Cool-down duration, samples are discarded")
+	flag.IntVar(&cfg.load.Concurrency, "concurrency", 50, "Number of concurrent workers")
+	flag.StringVar(&scenario, "scenario", string(client.ScenarioPeak), "Load scenario: light, peak, constant, open-loop")
+	flag.Float64Var(&cfg.load.TargetRPS, "rps", 0, "Target RPS for the open-loop scenario")
+	flag.StringVar(&profile, "profile", "", "Load profile, e.g. ramp:from=10,to=500,duration=30s")
+	flag.DurationVar(&cfg.load.Interval, "interval", time.Second, "Per-interval snapshot period, printed live and saved in results (0: off)")
+	flag.DurationVar(&cfg.load.Timeout, "timeout", 5*time.Second, "Per-call timeout (0: no timeout)")
+	flag.StringVar(&rpcTimeouts, "rpc-timeouts", "", "Per-RPC timeouts overriding -timeout, e.g. Ping=100ms,StreamPing=1s")
+	flag.IntVar(&cfg.load.StreamWindow, "stream-window", 0, "StreamPing: keep long-lived streams with N messages in flight each (0: new stream per call)")
+	flag.IntVar(&cfg.load.Batch.Messages, "batch-messages", 1, "AggregatePing: messages per client stream")
+	flag.DurationVar(&cfg.load.Batch.Gap, "batch-gap", 0, "AggregatePing: pause between messages in a stream")
+	flag.Var(&cfg.load.Payload.Size, "request-size", "Request payload size: 1KiB, uniform:64-4KiB or dist:64=70,1KiB=30 (percent weights)")
+	flag.Var(&cfg.load.Payload.ResponseSize, "response-size", "Response payload size requested from the server, same syntax as -request-size")
+	flag.StringVar(&sweepSizes, "sweep-sizes", "", "Run every benchmark once per payload size: 64,1KiB,64KiB or 64B..4MiB (x2 steps, 64B..4MiB*4 for x4), up to 16MiB")
+	flag.StringVar(&cfg.sweepSide, "sweep-side", client.SweepRequest, "Payload swept by -sweep-sizes: request, response, both")
+	flag.StringVar(&compressions, "compression", "", "Message compression: "+strings.Join(client.Compressions, ", ")+"; a comma list compares algorithms")
+	flag.StringVar(&fills, "payload-fill", "", "Payload contents: "+strings.Join(client.PayloadFills, ", ")+" (empty: random); a comma list compares them")
+	flag.IntVar(&cfg.load.Push.Count, "push-count", 0, "PushNotifications: messages per stream requested from the server (0: server default)")
+	flag.DurationVar(&cfg.load.Push.Interval, "push-interval", 0, "PushNotifications: interval before each message (mean for random distributions)")
+	flag.StringVar(&cfg.load.Push.Distribution, "push-distribution", "fixed", "PushNotifications: interval distribution: fixed, uniform, exponential")
+	flag.IntVar(&cfg.load.Push.PayloadSize, "push-payload-size", 0, "PushNotifications: response message size in bytes (0: as is)")
+	flag.StringVar(&cfg.pushMsg, "push-message", "start", "Request message for PushNotifications")
+	flag.DurationVar(&cfg.startDelay, "start-delay", 0, "Delay before the first benchmark")
+	flag.StringVar(&cfg.format, "format", client.FormatText, "Output format: "+strings.Join(client.Formats, ", "))
+	flag.StringVar(&cfg.out, "out", "", "Write results to file instead of stdout")
+	flag.StringVar(&cfg.openMetricsOut, "openmetrics-out", "", "Also write results to this file in OpenMetrics text format")
+	flag.StringVar(&cfg.metricsPort, "metrics-port", "", "Serve client Prometheus metrics on this address during the run, e.g. :9092 (empty: off)")
+	flag.StringVar(&cfg.traceEndpoint, "trace-endpoint", "", "Send client spans to this Jaeger collector, e.g. http://localhost:14268/api/traces (empty: no tracing)")
+	flag.Float64Var(&cfg.traceSample, "trace-sample", 1, "Fraction of calls to trace, from 0 to 1")
+	flag.StringVar(&cfg.coordinator, "coordinator", "", "Run as coordinator: listen for agents on this address, e.g. :7070")
+	flag.IntVar(&cfg.agents, "agents", 1, "Coordinator: number of agents that share the load")
+	flag.DurationVar(&cfg.agentWait, "agent-wait", time.Minute, "Coordinator: how long to wait for all agents to join")
+	flag.StringVar(&cfg.join, "join", "", "Run as agent of the coordinator at this address; load parameters come from the coordinator")
+	flag.StringVar(&cfg.agentName, "agent-name", "", "Agent name in coordinator logs (default: host:pid)")
+	flag.Var(&cfg.slos, "slo", "SLO to check, e.g. Ping:p99<20ms,error_rate<0.5%,rps>=5000; a method prefix applies to the rest of the list (repeatable)")
+
+	err := applyEnv(flag.CommandLine)
+	if err != nil {
+		return nil, err
+	}
+	flag.Parse()
+
+	if cfg.load.Scenario, err = client.ParseScenario(scenario); err != nil {
+		return nil, err
+	}
+
+	if profile != "" {
+		p, target, err := client.ParseProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.load.Profile, cfg.load.ProfileTarget = p, target
+	}
+
+	if rpcTimeouts != "" {
+		if cfg.load.RPCTimeouts, err = client.ParseRPCTimeouts(rpcTimeouts); err != nil {
+			return nil, err
+		}
+	}
+
+	if sweepSizes != "" {
+		if cfg.sweepSizes, err = client.ParseSweepSizes(sweepSizes); err != nil {
+			return nil, err
+		}
+	}
+
+	if compressions != "" {
+		cfg.compressions = strings.Split(compressions, ",")
+	}
+	cfg.fills = strings.Split(fills, ",")
+	if len(cfg.compressions) <= 1 && len(cfg.fills) <= 1 {
+		// один алгоритм и один вид payload — обычный прогон, без сравнения
+		if len(cfg.compressions) == 1 {
+			cfg.load.Compression = cfg.compressions[0]
+		}
+		cfg.load.Payload.Fill = cfg.fills[0]
+		cfg.compressions, cfg.fills = nil, nil
+	}
+
+	if mix != "" {
+		if cfg.mix, err = client.ParseMix(mix); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range strings.Split(rpcs, ",") {
+		rpc, err := client.RPCName(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		cfg.rpcs = append(cfg.rpcs, rpc)
+	}
+
+	if cfg.coordinator != "" && cfg.join != "" {
+		return nil, fmt.Errorf("-coordinator и -join взаимоисключающие")
+	}
+	if cfg.call != "" {
+		switch {
+		case cfg.plan != "" || len(cfg.mix) > 0:
+			return nil, fmt.Errorf("-call не сочетается с -plan и -mix")
+		case cfg.coordinator != "" || cfg.join != "":
+			return nil, fmt.Errorf("-call не поддерживается в распределённом прогоне")
+		case len(cfg.sweepSizes) > 0 || len(cfg.compressions) > 0 || len(cfg.fills) > 0:
+			return nil, fmt.Errorf("-call не сочетается с -sweep-sizes и сравнением сжатия: размер и содержимое запроса задаёт -data")
+		}
+	}
+	if cfg.agents < 1 {
+		return nil, fmt.Errorf("-agents должен быть не меньше 1")
+	}
+
+	if cfg.traceSample < 0 || cfg.traceSample > 1 {
+		return nil, fmt.Errorf("-trace-sample должен быть от 0 до 1")
+	}
+
+	if cfg.count < 1 {
+		return nil, fmt.Errorf("-count должен быть не меньше 1")
+	}
+
+	if !slices.Contains(client.Formats, cfg.format) {
+		return nil, fmt.Errorf("неизвестный формат вывода %q", cfg.format)
+	}
+	return cfg, nil
+}
+
+// tlsConfig — настройки TLS из флагов; цели плана могут их переопределить
+func (c *config) tlsConfig() client.TLSConfig {
+	return client.TLSConfig{Insecure: c.insecure, CA: c.caFile, Cert: c.certFile, Key: c.keyFile}
+}
+
+// sloList — значение флага -slo: флаг можно повторять, а несколько SLO
+// перечислять через запятую (см. client.ParseSLOList). Флаги командной
+// строки заменяют SLO из BENCH_SLO, а не дополняют их.
+type sloList struct {
+	slos    []client.SLO
+	fromEnv bool // slos заданы переменной окружения
+}
+
+// String перечисляет сначала общие SLO, затем SLO методов, чтобы
+// строка разбиралась обратно в те же SLO
+func (l *sloList) String() string {
+	var global, scoped []string
+	for _, slo := range l.slos {
+		if slo.Method == "" {
+			global = append(global, slo.String())
+		} else {
+			scoped = append(scoped, slo.String())
+		}
+	}
+	return strings.Join(append(global, scoped...), ",")
+}
+
+func (l *sloList) Set(v string) error {
+	slos, err := client.ParseSLOList(v)
+	if err != nil {
+		return err
+	}
+	if l.fromEnv {
+		l.slos, l.fromEnv = nil, false
+	}
+	l.slos = append(l.slos, slos...)
+	return nil
+}
+
+func (l *sloList) setFromEnv() { l.fromEnv = true }
+
+// envValue — повторяемый флаг, которому нужно знать, что значение пришло
+// из окружения: тогда первый флаг командной строки его заменяет
+type envValue interface {
+	setFromEnv()
+}
+
+// applyEnv задаёт значения флагов из переменных окружения BENCH_*
+func applyEnv(fs *flag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		v, ok := os.LookupEnv(name)
+		if !ok || err != nil {
+			return
+		}
+		if setErr := f.Value.Set(v); setErr != nil {
+			err = fmt.Errorf("переменная %s: %v", name, setErr)
+			return
+		}
+		if ev, ok := f.Value.(envValue); ok {
+			ev.setFromEnv()
+		}
+	})
+	return err
+}
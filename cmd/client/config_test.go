@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSLOFlagReplacesEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"только окружение", nil, "error_rate<1%"},
+		{"флаг заменяет окружение", []string{"-slo", "Ping:p99<20ms"}, "Ping:p99<20ms"},
+		{"повторы флага складываются", []string{"-slo", "Ping:p99<20ms", "-slo", "rps>=100"}, "rps>=100,Ping:p99<20ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envPrefix+"SLO", "error_rate<1%")
+			fs := flag.NewFlagSet("client", flag.ContinueOnError)
+			var slos sloList
+			fs.Var(&slos, "slo", "")
+			if err := applyEnv(fs); err != nil {
+				t.Fatal(err)
+			}
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if got := slos.String(); got != tt.want {
+				t.Errorf("SLO %q, ожидается %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		want    string // concurrency/start-delay/insecure после разбора
+		wantErr string
+	}{
+		{"по умолчанию", nil, nil, "50 0s false", ""},
+		{"из окружения", map[string]string{"BENCH_CONCURRENCY": "8", "BENCH_START_DELAY": "2s", "BENCH_INSECURE": "true"}, nil, "8 2s true", ""},
+		{"флаг важнее окружения", map[string]string{"BENCH_CONCURRENCY": "8"}, []string{"-concurrency", "3"}, "3 0s false", ""},
+		{"переменная без префикса не читается", map[string]string{"CONCURRENCY": "8"}, nil, "50 0s false", ""},
+		{"неверное значение", map[string]string{"BENCH_START_DELAY": "soon"}, nil, "", "переменная BENCH_START_DELAY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			fs := flag.NewFlagSet("client", flag.ContinueOnError)
+			concurrency := fs.Int("concurrency", 50, "")
+			delay := fs.Duration("start-delay", 0, "")
+			insecure := fs.Bool("insecure", false, "")
+			err := applyEnv(fs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидается %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(*concurrency, *delay, *insecure); got != tt.want {
+				t.Errorf("%s, ожидается %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/client"
+	"github.com/go-portfolio/go-grpc-benchmark/internal/payload"
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// startCoordinator принимает агентов на адресе -coordinator, ждёт
+// -agents агентов и возвращает исполнителя, раздающего этапы агентам.
+// Связь с агентами — без TLS, она рассчитана на доверенную сеть.
+func startCoordinator(cfg *config) (stageRunner, func(), error) {
+	lis, err := net.Listen("tcp", cfg.coordinator)
+	if err != nil {
+		return nil, nil, fmt.Errorf("координатор: %v", err)
+	}
+	coord := client.NewCoordinator(cfg.agents)
+	srv := grpc.NewServer()
+	pb.RegisterCoordinatorServiceServer(srv, coord)
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			log.Printf("Координатор: %v", err)
+		}
+	}()
+	log.Printf("Координатор слушает %s, ожидание агентов: %d", lis.Addr(), cfg.agents)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.agentWait)
+	defer cancel()
+	if err := coord.Wait(ctx); err != nil {
+		srv.Stop()
+		return nil, nil, err
+	}
+
+	runStage := func(st client.Stage, t client.PlanTarget) ([]*client.Result, error) {
+		if t.TLS != nil {
+			log.Printf("Цель %s: агенты подключаются с TLS из своих флагов, блок tls плана не используется", t.TargetName())
+		}
+		return coord.Run(st, t.Address)
+	}
+	stop := func() {
+		coord.Close()
+		srv.GracefulStop()
+	}
+	return runStage, stop, nil
+}
+
+// runAgent подключается к координатору -join и выполняет его задания.
+// К серверам агент подключается с TLS из своих флагов.
+func runAgent(cfg *config) error {
+	conn, err := grpc.Dial(cfg.join, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	name := cfg.agentName
+	if name == "" {
+		host, _ := os.Hostname()
+		name = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+
+	// Этапы приходят от координатора позже подключения к целям,
+	// поэтому лимиты сообщений рассчитаны на любой допустимый payload
+	cfg.maxPayload = payload.MaxSize
+	var mu sync.Mutex
+	clients := map[string]pb.BenchmarkServiceClient{}
+	dial := func(target string) (pb.BenchmarkServiceClient, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if c, ok := clients[target]; ok {
+			return c, nil
+		}
+		conn, err := cfg.dialTarget(target, cfg.tlsConfig())
+		if err != nil {
+			return nil, fmt.Errorf("цель %s: %v", target, err)
+		}
+		clients[target] = client.NewBenchmarkClientWithConn(conn)
+		return clients[target], nil
+	}
+	return client.RunAgent(pb.NewCoordinatorServiceClient(conn), name, dial)
+}
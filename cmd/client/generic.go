@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/client"
+)
+
+// reflectionTimeout ограничивает запрос описания сервиса через reflection
+const reflectionTimeout = 10 * time.Second
+
+// genericRunner подключается к -target и выполняет этапы вызовами
+// произвольного метода -call. Описание метода берётся из -protoset,
+// а без него — у сервера через gRPC reflection.
+func (p *benchPlan) genericRunner(cfg *config) (runStage stageRunner, closeConn func(), err error) {
+	conn, err := cfg.dialTarget(cfg.target, cfg.tlsConfig())
+	if err != nil {
+		return nil, nil, fmt.Errorf("цель %s: %v", cfg.target, err)
+	}
+	call, err := resolveCall(cfg, conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("%s: %v", cfg.call, err)
+	}
+	runStage = func(st client.Stage, _ client.PlanTarget) ([]*client.Result, error) {
+		res, err := client.Generic(conn, call, st.Load)
+		if err != nil {
+			return nil, err
+		}
+		return []*client.Result{res}, nil
+	}
+	return runStage, func() { conn.Close() }, nil
+}
+
+// resolveCall находит метод -call и разбирает шаблон запроса -data
+func resolveCall(cfg *config, conn *grpc.ClientConn) (client.GenericCall, error) {
+	files, err := callFiles(cfg, conn)
+	if err != nil {
+		return client.GenericCall{}, err
+	}
+	method, err := client.FindMethod(files, cfg.call)
+	if err != nil {
+		return client.GenericCall{}, err
+	}
+
+	data := cfg.data
+	if path, ok := strings.CutPrefix(data, "@"); ok {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return client.GenericCall{}, err
+		}
+		data = string(b)
+	}
+	tmpl, err := client.ParseRequestTemplate(data, method.Input())
+	if err != nil {
+		return client.GenericCall{}, err
+	}
+	return client.GenericCall{Method: method, Template: tmpl}, nil
+}
+
+// callFiles загружает описания из -protoset или, без него, запрашивает
+// у сервера через reflection файл с сервисом метода -call
+func callFiles(cfg *config, conn *grpc.ClientConn) (*protoregistry.Files, error) {
+	if cfg.protoset != "" {
+		return client.LoadProtoset(cfg.protoset)
+	}
+	service, _, err := client.ParseMethodName(cfg.call)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reflectionTimeout)
+	defer cancel()
+	return client.ReflectFiles(ctx, conn, service)
+}
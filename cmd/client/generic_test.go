@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/client"
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// reflectionConn поднимает в памяти сервер с BenchmarkService и reflection
+func reflectionConn(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterBenchmarkServiceServer(srv, pb.UnimplementedBenchmarkServiceServer{})
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestResolveCallReflection(t *testing.T) {
+	conn := reflectionConn(t)
+	tests := []struct {
+		call    string
+		data    string
+		wantErr string
+	}{
+		{call: "benchmark.BenchmarkService/Ping", data: `{"message":"{{.Seq}}"}`},
+		{call: "/benchmark.BenchmarkService/StreamPing", data: "{}"},
+		{call: "benchmark.BenchmarkService.AggregatePing", data: "{}"},
+		{call: "benchmark.BenchmarkService/Nope", data: "{}", wantErr: "нет метода Nope"},
+		{call: "benchmark.Missing/Ping", data: "{}", wantErr: "reflection: сервис benchmark.Missing"},
+		{call: "benchmark.BenchmarkService/Ping", data: `{"unknown":1}`, wantErr: "запрос benchmark.PingRequest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.call, func(t *testing.T) {
+			call, err := resolveCall(&config{call: tt.call, data: tt.data}, conn)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидается %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, method, _ := client.ParseMethodName(tt.call); call.Method.Name() != method {
+				t.Errorf("метод %s, ожидается %s", call.Method.FullName(), method)
+			}
+		})
+	}
+}
+
+// Ошибка подключения при запросе reflection не должна теряться и
+// превращаться в «сервис не найден»
+func TestResolveCallUnreachable(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	lis.Close()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	_, err = resolveCall(&config{call: "benchmark.BenchmarkService/Ping", data: "{}"}, conn)
+	if err == nil || !strings.Contains(err.Error(), "reflection") || strings.Contains(err.Error(), "не найден") {
+		t.Fatalf("ошибка %v, ожидается ошибка подключения reflection", err)
+	}
+}
@@ -3,84 +3,179 @@ package main
 import (
 	"crypto/tls"
 	"crypto/x509"
-	"flag"
-	"io/ioutil"
+	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"github.com/go-portfolio/go-grpc-benchmark/internal/client"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
+// exitSLOViolation — код выхода, если результаты нарушают заданные SLO.
+// Код 1 остаётся за ошибками запуска (log.Fatal).
+const exitSLOViolation = 3
+
+// transportCredentials собирает TLS/mTLS настройки подключения
+func transportCredentials(tc client.TLSConfig) (credentials.TransportCredentials, error) {
+	if tc.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: tc.ServerName}
+	if tc.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(tc.Cert, tc.Key)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка загрузки клиентского сертификата: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if tc.CA != "" {
+		caCert, err := os.ReadFile(tc.CA)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения CA: %v", err)
+		}
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(caCert)
+		tlsConfig.RootCAs = caCertPool
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
 func main() {
-	time.Sleep(2 * time.Second)
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		os.Exit(runCompare(os.Args[2:]))
+	}
+
+	cfg, err := setupFlags()
+	if err != nil {
+		log.Fatalf("Ошибка параметров: %v", err)
+	}
 
 	// Инициализация логгера
-	if err := client.InitLogger("../../logs/client.log"); err != nil {
+	if err := client.InitLogger(cfg.logFile); err != nil {
 		log.Fatalf("Ошибка инициализации логов: %v", err)
 	}
 	defer client.CloseLogger()
 
-	debug := flag.Bool("debug", false, "Enable debug logs")
-	verbose := flag.Bool("verbose", false, "Enable verbose logs")
-	flag.Parse()
-
-	client.Debug = *debug
-	client.Verbose = *verbose
+	client.Debug = cfg.debug
+	client.Verbose = cfg.verbose
 
-	if *debug {
+	if cfg.debug {
 		log.Println("Debug mode enabled")
 	}
-	if *verbose {
+	if cfg.verbose {
 		log.Println("Verbose logging enabled")
 	}
 
-	// === TLS ===
-	cert, err := tls.LoadX509KeyPair("certs/client.crt", "certs/client.key")
-	if err != nil {
-		log.Fatalf("Ошибка загрузки клиентского сертификата: %v", err)
+	if cfg.metricsPort != "" {
+		if err := client.StartMetricsEndpoint(cfg.metricsPort); err != nil {
+			log.Fatalf("%v", err)
+		}
 	}
 
-	caCert, err := ioutil.ReadFile("certs/ca.crt")
-	if err != nil {
-		log.Fatalf("Ошибка чтения CA: %v", err)
+	shutdownTracer := func() {}
+	if cfg.traceEndpoint != "" {
+		if shutdownTracer, err = initTracer(cfg.traceEndpoint, cfg.traceSample); err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer shutdownTracer()
 	}
-	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM(caCert)
 
-	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{cert},
-		RootCAs:      caCertPool,
+	if cfg.join != "" {
+		if err := runAgent(cfg); err != nil {
+			log.Fatalf("Агент: %v", err)
+		}
+		return
 	}
 
-	creds := credentials.NewTLS(tlsConfig)
-
-	conn, err := grpc.Dial("localhost:50051", grpc.WithTransportCredentials(creds))
+	plan, err := loadPlan(cfg)
 	if err != nil {
-		log.Fatalf("Ошибка подключения: %v", err)
+		log.Fatalf("Ошибка плана: %v", err)
 	}
-	defer conn.Close()
 
-	c := client.NewBenchmarkClientWithConn(conn)
+	time.Sleep(cfg.startDelay)
+
+	var runStage stageRunner
+	var closeRunner func()
+	switch {
+	case cfg.coordinator != "":
+		runStage, closeRunner, err = startCoordinator(cfg)
+	case cfg.call != "":
+		runStage, closeRunner, err = plan.genericRunner(cfg)
+	default:
+		runStage, closeRunner, err = plan.localRunner(cfg)
+	}
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	report := &client.Report{Target: plan.addresses(), StartedAt: time.Now()}
+	err = plan.run(report, runStage)
+	closeRunner()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
-	// Настройки нагрузки
-	requestsUnary := 1000           // количество запросов для UnaryPing
-	concurrencyUnary := 50          // параллельные горутины для UnaryPing
-	requestsStream := 100           // количество сообщений для StreamPing
-	requestsAggregate := 50         // количество сообщений для AggregatePing
-	scenario := client.ScenarioPeak // light, peak, constant
+	if len(cfg.slos.slos) > 0 {
+		report.SLO = append(report.SLO, client.CheckSLOs(cfg.slos.slos, report.Results)...)
+	}
 
-	// 1️⃣ UnaryPing с нагрузкой
-	client.UnaryPing(c, requestsUnary, concurrencyUnary, scenario)
+	if err := writeReport(cfg, report); err != nil {
+		log.Fatalf("Ошибка записи результатов: %v", err)
+	}
+	if cfg.openMetricsOut != "" {
+		if err := writeOpenMetrics(cfg.openMetricsOut, report); err != nil {
+			log.Fatalf("Ошибка записи OpenMetrics: %v", err)
+		}
+	}
 
-	// 2️⃣ StreamPing с нагрузкой
-	client.StreamPing(c, requestsStream, concurrencyUnary, scenario)
+	if !client.SLOsPassed(report.SLO) {
+		log.Println("SLO нарушены")
+		shutdownTracer()
+		client.CloseLogger()
+		os.Exit(exitSLOViolation)
+	}
+}
 
-	// 3️⃣ PushNotifications с нагрузкой
-	client.PushNotifications(c, "start")
+// writeReport выводит результаты в выбранном формате в stdout или в файл -out.
+// При записи в файл сводка для человека дополнительно печатается в stdout.
+func writeReport(cfg *config, report *client.Report) error {
+	if cfg.out == "" {
+		return client.WriteReport(os.Stdout, cfg.format, report)
+	}
 
-	// 4️⃣ AggregatePing с нагрузкой
-	client.AggregatePing(c, requestsAggregate, concurrencyUnary, scenario)
+	f, err := os.Create(cfg.out)
+	if err != nil {
+		return err
+	}
+	if err := client.WriteReport(f, cfg.format, report); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("Результаты записаны в %s", cfg.out)
+	if cfg.format != client.FormatText {
+		return client.WriteText(os.Stdout, report)
+	}
+	return nil
+}
 
+// writeOpenMetrics записывает итоги прогона в файл -openmetrics-out
+func writeOpenMetrics(path string, report *client.Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := client.WriteOpenMetrics(f, report); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("Метрики OpenMetrics записаны в %s", path)
+	return nil
 }
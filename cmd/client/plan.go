@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/client"
+	"github.com/go-portfolio/go-grpc-benchmark/internal/payload"
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// benchPlan — цели, этапы и число повторов, которые выполняет клиент
+type benchPlan struct {
+	targets []client.PlanTarget
+	stages  []client.Stage
+	count   int
+}
+
+// loadPlan собирает план из файла -plan, а без него — из флагов:
+// по одному этапу на каждый RPC из -rpcs. С -sweep-sizes каждый этап
+// без собственной серии размножается по размерам payload, а со списком
+// в -compression или -payload-fill — по алгоритмам сжатия и видам payload.
+// Наибольший payload этапов запоминается в cfg.maxPayload для dialTarget.
+func loadPlan(cfg *config) (*benchPlan, error) {
+	p, err := readPlan(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.sweepSizes) > 0 {
+		p.stages, err = expandStages(p.stages, func(st client.Stage) ([]client.Stage, error) {
+			if st.Load.Sweep != "" {
+				return []client.Stage{st}, nil
+			}
+			return client.Sweep(st, cfg.sweepSizes, cfg.sweepSide)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.compressions) > 0 || len(cfg.fills) > 0 {
+		p.stages, err = expandStages(p.stages, func(st client.Stage) ([]client.Stage, error) {
+			if st.Load.CompressionSeries {
+				return []client.Stage{st}, nil
+			}
+			return client.CompareCompression(st, cfg.compressions, cfg.fills)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, st := range p.stages {
+		cfg.maxPayload = max(cfg.maxPayload, st.Load.MaxPayload())
+	}
+	return p, nil
+}
+
+// stageTitle — как называть этап в ошибках: по имени из плана, а без
+// него — по RPC или смеси
+func stageTitle(st client.Stage) string {
+	switch {
+	case st.Name != "":
+		return st.Name
+	case len(st.Mix) > 0:
+		return "mix " + client.FormatMix(st.Mix)
+	}
+	return st.RPC
+}
+
+// expandStages заменяет каждый этап этапами, которые возвращает expand
+func expandStages(stages []client.Stage, expand func(client.Stage) ([]client.Stage, error)) ([]client.Stage, error) {
+	var out []client.Stage
+	for _, st := range stages {
+		series, err := expand(st)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, series...)
+	}
+	return out, nil
+}
+
+// readPlan читает план из файла -plan или собирает его из флагов
+func readPlan(cfg *config) (*benchPlan, error) {
+	if cfg.plan == "" {
+		p := &benchPlan{
+			targets: []client.PlanTarget{{Address: cfg.target}},
+			count:   cfg.count,
+		}
+		if len(cfg.mix) > 0 {
+			p.stages = []client.Stage{{Mix: cfg.mix, Load: cfg.load}}
+			return p, nil
+		}
+		if cfg.call != "" {
+			p.stages = []client.Stage{{RPC: cfg.call, Load: cfg.load}}
+			return p, nil
+		}
+		for _, rpc := range cfg.rpcs {
+			st := client.Stage{RPC: rpc, Load: cfg.load}
+			if rpc == "PushNotifications" {
+				st.Load.Payload.Message = cfg.pushMsg
+			}
+			p.stages = append(p.stages, st)
+		}
+		return p, nil
+	}
+
+	plan, err := client.LoadPlan(cfg.plan)
+	if err != nil {
+		return nil, err
+	}
+	stages, err := plan.Build(cfg.load)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", cfg.plan, err)
+	}
+	p := &benchPlan{targets: plan.Targets, stages: stages, count: plan.Count}
+	if len(p.targets) == 0 {
+		p.targets = []client.PlanTarget{{Address: cfg.target}}
+	}
+	if p.count <= 0 {
+		p.count = cfg.count
+	}
+	return p, nil
+}
+
+// addresses — адреса всех целей плана для заголовка отчёта
+func (p *benchPlan) addresses() string {
+	addrs := make([]string, len(p.targets))
+	for i, t := range p.targets {
+		addrs[i] = t.Address
+	}
+	return strings.Join(addrs, ",")
+}
+
+// stageRunner выполняет этап на одной цели плана
+type stageRunner func(st client.Stage, t client.PlanTarget) ([]*client.Result, error)
+
+// localRunner подключается к целям плана и выполняет этапы в этом
+// процессе. close закрывает соединения.
+func (p *benchPlan) localRunner(cfg *config) (runStage stageRunner, closeConns func(), err error) {
+	clients := map[string]pb.BenchmarkServiceClient{}
+	var conns []*grpc.ClientConn
+	closeConns = func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}
+	for _, t := range p.targets {
+		tc := cfg.tlsConfig()
+		if t.TLS != nil {
+			tc = *t.TLS
+		}
+		conn, err := cfg.dialTarget(t.Address, tc)
+		if err != nil {
+			closeConns()
+			return nil, nil, fmt.Errorf("цель %s: %v", t.TargetName(), err)
+		}
+		conns = append(conns, conn)
+		clients[t.TargetName()] = client.NewBenchmarkClientWithConn(conn)
+	}
+	runStage = func(st client.Stage, t client.PlanTarget) ([]*client.Result, error) {
+		return st.Run(clients[t.TargetName()])
+	}
+	return runStage, closeConns, nil
+}
+
+// dialTarget подключается к серверу бенчмарка с учётом трафика вызовов,
+// с -metrics-port — и с метриками Prometheus по каждому вызову, а с
+// -trace-endpoint — со span'ами вызовов и передачей контекста трассы.
+// Лимиты размера сообщений вмещают payload до c.maxPayload.
+func (c *config) dialTarget(address string, tc client.TLSConfig) (*grpc.ClientConn, error) {
+	creds, err := transportCredentials(tc)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка TLS: %v", err)
+	}
+	msgLimit := payload.MessageSizeLimit(c.maxPayload)
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(client.StatsHandler()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(msgLimit), grpc.MaxCallSendMsgSize(msgLimit)),
+	}
+	if c.metricsPort != "" {
+		opts = append(opts, grpc.WithStatsHandler(client.MetricsHandler()))
+	}
+	if c.traceEndpoint != "" {
+		opts = append(opts, grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	}
+	conn, err := grpc.Dial(address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения: %v", err)
+	}
+	return conn, nil
+}
+
+// run выполняет этапы через runStage. Повторы идут по кругу через все
+// этапы, чтобы медленный дрейф окружения распределялся по бенчмаркам
+// равномерно. SLO этапа проверяются на его результатах.
+func (p *benchPlan) run(report *client.Report, runStage stageRunner) error {
+	for run := 1; run <= p.count; run++ {
+		for _, st := range p.stages {
+			for _, t := range p.targets {
+				name := t.TargetName()
+				if len(st.Targets) > 0 && !slices.Contains(st.Targets, name) {
+					continue
+				}
+				if st.Name != "" {
+					log.Printf("Этап %s, цель %s", st.Name, name)
+				}
+				results, err := runStage(st, t)
+				if err != nil {
+					return fmt.Errorf("%s: %v", stageTitle(st), err)
+				}
+				for _, res := range results {
+					res.Stage = st.Name
+					if len(p.targets) > 1 {
+						res.Target = name
+					}
+					if p.count > 1 {
+						res.Run = run
+					}
+				}
+				report.Results = append(report.Results, results...)
+				if len(st.SLO) > 0 {
+					report.SLO = append(report.SLO, client.CheckSLOs(st.SLO, results)...)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/client"
+)
+
+func TestPlanRunErrorNamesStage(t *testing.T) {
+	mix := []client.MixEntry{{RPC: "Ping", Weight: 80}, {RPC: "StreamPing", Weight: 20}}
+	tests := []struct {
+		stage client.Stage
+		want  string
+	}{
+		{client.Stage{Name: "burst", RPC: "Ping"}, "burst: отказ"},
+		{client.Stage{RPC: "Ping"}, "Ping: отказ"},
+		{client.Stage{Mix: mix}, "mix Ping=80,StreamPing=20: отказ"},
+	}
+	for _, tt := range tests {
+		p := &benchPlan{targets: []client.PlanTarget{{Address: "localhost:1"}}, stages: []client.Stage{tt.stage}, count: 1}
+		err := p.run(&client.Report{}, func(client.Stage, client.PlanTarget) ([]*client.Result, error) {
+			return nil, errors.New("отказ")
+		})
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ошибка %v, ожидается %q", err, tt.want)
+		}
+	}
+}
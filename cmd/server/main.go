@@ -9,6 +9,8 @@ import (
 	"os"
 	"time"
 
+	_ "github.com/go-portfolio/go-grpc-benchmark/internal/compression" // gzip и deflate: сервер отвечает тем же алгоритмом, что и клиент
+	"github.com/go-portfolio/go-grpc-benchmark/internal/payload"
 	"github.com/go-portfolio/go-grpc-benchmark/internal/server"
 	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
 
@@ -16,11 +18,13 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
 )
 
 func initTracer() (*sdktrace.TracerProvider, error) {
@@ -42,14 +46,22 @@ func initTracer() (*sdktrace.TracerProvider, error) {
 	)
 
 	otel.SetTracerProvider(tp)
+	// контекст трассы клиента из заголовков traceparent: span'ы сервера
+	// становятся дочерними span'ов клиента бенчмарка
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 	return tp, nil
 }
 
 // setupFlags парсит флаги командной строки
-func setupFlags() (debug, verbose *bool, metricsPort *string) {
+func setupFlags() (debug, verbose *bool, metricsPort *string, limits server.StreamLimits, maxPayload int) {
 	debug = flag.Bool("debug", false, "Enable debug mode")
 	verbose = flag.Bool("verbose", false, "Enable verbose logging")
 	metricsPort = flag.String("metrics-port", ":9090", "Prometheus metrics endpoint")
+	limits = server.DefaultStreamLimits
+	flag.IntVar(&limits.MaxMessages, "push-max-messages", limits.MaxMessages, "Max messages per PushNotifications stream requested by a client")
+	flag.DurationVar(&limits.MaxInterval, "push-max-interval", limits.MaxInterval, "Max interval between PushNotifications messages requested by a client")
+	flag.IntVar(&limits.MaxPayloadSize, "push-max-payload", limits.MaxPayloadSize, "Max PushNotifications message size in bytes requested by a client")
+	flag.IntVar(&maxPayload, "max-payload", payload.MaxSize, "Max request payload and response_size in bytes; gRPC message limits are raised to fit it")
 	flag.Parse()
 	return
 }
@@ -69,7 +81,7 @@ func main() {
 	// ------------------------------
 	// Флаги командной строки
 	// ------------------------------
-	debug, verbose, metricsPort := setupFlags()
+	debug, verbose, metricsPort, limits, maxPayload := setupFlags()
 	if *debug {
 		server.Info("Debug mode enabled")
 	}
@@ -113,8 +125,13 @@ func main() {
 
 	grpc_prometheus.EnableHandlingTimeHistogram()
 
+	// Лимиты сообщений вмещают payload до -max-payload в обе стороны,
+	// иначе серия -sweep-sizes упирается в стандартные 4MiB gRPC
+	msgLimit := payload.MessageSizeLimit(maxPayload)
 	grpcServer := grpc.NewServer(
 		grpc.Creds(creds),
+		grpc.MaxRecvMsgSize(msgLimit),
+		grpc.MaxSendMsgSize(msgLimit),
 		grpc.ChainUnaryInterceptor(
 			grpc_prometheus.UnaryServerInterceptor, // сначала Prometheus
 			server.PrometheusUnaryInterceptor,      // ваш кастомный, если нужен
@@ -127,7 +144,12 @@ func main() {
 	)
 
 	srv := server.NewServer(*debug, *verbose)
+	srv.SetStreamLimits(limits)
+	srv.SetMaxPayloadSize(maxPayload)
 	pb.RegisterBenchmarkServiceServer(grpcServer, srv)
+	// reflection позволяет вызывать сервис без .proto, например
+	// клиентом бенчмарка в режиме -call или grpcurl
+	reflection.Register(grpcServer)
 	grpc_prometheus.Register(grpcServer)
 
 	// ------------------------------
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// DialFunc возвращает клиент сервиса бенчмарка для адреса сервера
+type DialFunc func(target string) (pb.BenchmarkServiceClient, error)
+
+// RunAgent подключается к координатору под именем name и выполняет
+// его задания, пока координатор не сообщит, что заданий больше нет.
+// К серверам из заданий агент подключается через dial.
+func RunAgent(coordinator pb.CoordinatorServiceClient, name string, dial DialFunc) error {
+	stream, err := coordinator.Join(context.Background())
+	if err != nil {
+		return err
+	}
+	// Снимки интервалов отправляются из горутин timeline параллельно
+	// с основным циклом, а Send потока нельзя вызывать одновременно
+	var mu sync.Mutex
+	send := func(msg *pb.AgentMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(msg)
+	}
+	hello := &pb.AgentHello{Name: name}
+	if err := send(&pb.AgentMessage{Body: &pb.AgentMessage_Hello{Hello: hello}}); err != nil {
+		return err
+	}
+	log.Printf("Агент %s подключился к координатору, ожидание заданий", name)
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return fmt.Errorf("координатор закрыл сессию")
+		}
+		if err != nil {
+			return err
+		}
+		if msg.GetDone() {
+			log.Printf("Заданий больше нет, агент завершает работу")
+			return stream.CloseSend()
+		}
+		if probe := msg.GetClock(); probe != nil {
+			reply := &pb.ClockReply{ProbeUnixNano: probe.UnixNano, AgentUnixNano: time.Now().UnixNano()}
+			if err := send(&pb.AgentMessage{Body: &pb.AgentMessage_Clock{Clock: reply}}); err != nil {
+				return err
+			}
+			continue
+		}
+		task := msg.GetTask()
+		if task == nil {
+			continue
+		}
+		result := &pb.TaskResult{Task: task.Id}
+		if err := runTask(task, dial, send, result); err != nil {
+			log.Printf("Задание %d: %v", task.Id, err)
+			result.Error = err.Error()
+		}
+		if err := send(&pb.AgentMessage{Body: &pb.AgentMessage_Result{Result: result}}); err != nil {
+			return err
+		}
+	}
+}
+
+// runTask выполняет свою долю этапа из задания и записывает итог в result
+func runTask(task *pb.AgentTask, dial DialFunc, send func(*pb.AgentMessage) error, result *pb.TaskResult) error {
+	var spec StageSpec
+	if err := json.Unmarshal(task.Stage, &spec); err != nil {
+		return fmt.Errorf("этап: %v", err)
+	}
+	st, err := BuildStage(spec)
+	if err != nil {
+		return err
+	}
+	if st.Load, err = st.Load.Slice(int(task.Index), int(task.Agents)); err != nil {
+		return err
+	}
+	client, err := dial(task.Target)
+	if err != nil {
+		return err
+	}
+	st.Load.OnInterval = func(name string, snap Snapshot, latency *Histogram) {
+		data, err := json.Marshal(latency)
+		if err != nil {
+			LogInfo("Снимок %s: %v", name, err)
+			return
+		}
+		report := &pb.IntervalReport{
+			Task:      task.Id,
+			Name:      name,
+			OffsetNs:  int64(snap.Offset),
+			LengthNs:  int64(snap.Length),
+			Success:   snap.Success,
+			Failures:  snap.Failures,
+			Discarded: snap.Discarded,
+			Latency:   data,
+		}
+		if err := send(&pb.AgentMessage{Body: &pb.AgentMessage_Interval{Interval: report}}); err != nil {
+			LogInfo("Снимок %s не отправлен координатору: %v", name, err)
+		}
+	}
+
+	wait := time.Until(time.Unix(0, task.StartUnixNano))
+	if wait < 0 {
+		log.Printf("Задание %d получено с опозданием на %s, старт сразу", task.Id, -wait)
+	}
+	time.Sleep(wait)
+	log.Printf("Задание %d: агент %d из %d, воркеров: %d, цель %s", task.Id, task.Index+1, task.Agents, st.Load.Concurrency, task.Target)
+
+	results, err := st.Run(client)
+	if err != nil {
+		return err
+	}
+	if result.Results, err = json.Marshal(results); err != nil {
+		return err
+	}
+	metrics := make([]map[string]*Histogram, len(results))
+	for i, r := range results {
+		metrics[i] = r.metricHists
+	}
+	result.Metrics, err = json.Marshal(metrics)
+	return err
+}
@@ -2,99 +2,122 @@ package client
 
 import (
 	"context"
+	"io"
 	"log"
-	"math/rand"
-	"sort"
 	"strconv"
-	"sync"
 	"time"
 
+	"google.golang.org/grpc/status"
+
 	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
 )
 
-func AggregatePing(client pb.BenchmarkServiceClient, totalRequests int, concurrency int, scenario LoadScenario) {
-	log.Println("=== Client Streaming: AggregatePing ===")
-	var wg sync.WaitGroup
-	wg.Add(concurrency)
-
-	rand.Seed(time.Now().UnixNano())
-	var latencies []time.Duration
-	var mu sync.Mutex
-	var successCount, failCount int64
-
-	for g := 0; g < concurrency; g++ {
-		go func(workerID int) {
-			defer wg.Done()
-			for i := 0; i < totalRequests/concurrency; i++ {
-				reqStart := time.Now()
-
-				stream, err := client.AggregatePing(context.Background())
-				if err != nil {
-					mu.Lock()
-					failCount++
-					mu.Unlock()
-					log.Printf("Worker %d: Ошибка AggregatePing: %v", workerID, err)
-					continue
-				}
+// Метрики AggregatePing в Result.Metrics
+const (
+	MetricFirstSend      = "time_to_first_send" // от открытия потока до отправки первого сообщения
+	MetricStreamDuration = "stream_duration"    // от открытия потока до ответа сервера
+	MetricAckLatency     = "ack_latency"        // от закрытия отправки до ответа сервера
+)
 
-				msg := "aggregate ping #" + strconv.Itoa(i+1)
-				if err := stream.Send(&pb.PingRequest{Message: msg}); err != nil {
-					mu.Lock()
-					failCount++
-					mu.Unlock()
-					log.Printf("Worker %d: Ошибка отправки AggregatePing: %v", workerID, err)
-				} else {
-					mu.Lock()
-					successCount++
-					mu.Unlock()
-					LogDebug("Worker %d: Отправлено AggregatePing: %s", workerID, msg)
-				}
+// Batch — как AggregatePing отправляет сообщения в одном клиентском потоке.
+// Размер сообщений задаёт Payload.
+type Batch struct {
+	Messages int           // сообщений в потоке; 0 — одно
+	Gap      time.Duration // пауза между сообщениями
+}
 
-				resp, err := stream.CloseAndRecv()
-				if err != nil {
-					mu.Lock()
-					failCount++
-					mu.Unlock()
-					log.Printf("Worker %d: Ошибка получения AggregatePing ответа: %v", workerID, err)
-				} else {
-					latency := time.Since(reqStart)
-					mu.Lock()
-					latencies = append(latencies, latency)
-					mu.Unlock()
-					LogDebug("Worker %d: AggregatePing ответ: %s", workerID, resp.Message)
-				}
+func AggregatePing(client pb.BenchmarkServiceClient, opts LoadOptions) (*Result, error) {
+	log.Println("=== Client Streaming: AggregatePing ===")
+	return RunWorkload(AggregatePingWorkload(client, opts.Payload, opts.Batch), opts)
+}
 
-				// Симуляция сценариев нагрузки
-				switch scenario {
-				case ScenarioLight:
-					time.Sleep(100 * time.Millisecond)
-				case ScenarioPeak:
-					time.Sleep(time.Duration(rand.Intn(10)) * time.Millisecond)
-				case ScenarioConstant:
-					// без пауз
-				}
-			}
-		}(g)
+// AggregatePingWorkload — клиентские потоки: пачка сообщений и итоговый
+// ответ сервера. Кроме задержки итерации собираются метрики
+// MetricFirstSend, MetricStreamDuration и MetricAckLatency.
+func AggregatePingWorkload(client pb.BenchmarkServiceClient, payload Payload, batch Batch) Workload {
+	if batch.Messages < 1 {
+		batch.Messages = 1
 	}
+	return &aggregatePing{client: client, payload: payload, batch: batch}
+}
+
+type aggregatePing struct {
+	client  pb.BenchmarkServiceClient
+	payload Payload
+	batch   Batch
+	metrics MetricSet
+}
+
+func (w *aggregatePing) Name() string   { return "AggregatePing" }
+func (w *aggregatePing) Method() string { return pb.BenchmarkService_AggregatePing_FullMethodName }
 
-	wg.Wait()
+func (w *aggregatePing) Setup(context.Context) error    { return nil }
+func (w *aggregatePing) Teardown(context.Context) error { return nil }
 
-	// Расчет p50, p90, p99
-	var p50, p90, p99 time.Duration
-	if len(latencies) > 0 {
-		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
-		idx := func(p float64) int {
-			i := int(float64(len(latencies)) * p / 100.0)
-			if i >= len(latencies) {
-				i = len(latencies) - 1
+func (w *aggregatePing) Metrics() map[string]*Histogram {
+	return w.metrics.Histograms()
+}
+
+func (w *aggregatePing) Execute(ctx context.Context, it Iteration) error {
+	workerID := it.Worker
+	start := time.Now()
+	stream, err := w.client.AggregatePing(ctx)
+	if err != nil {
+		log.Printf("Worker %d: Ошибка AggregatePing: %v", workerID, err)
+		return WithPhase(PhaseDial, err)
+	}
+
+	for i := 0; i < w.batch.Messages; i++ {
+		if i > 0 && w.batch.Gap > 0 {
+			if err := pause(ctx, w.batch.Gap); err != nil {
+				return WithPhase(PhaseSend, err)
+			}
+		}
+		def := "aggregate ping #" + strconv.Itoa(it.Seq+1)
+		if w.batch.Messages > 1 {
+			def += "." + strconv.Itoa(i+1)
+		}
+		msg := w.payload.message(def)
+		if err := stream.Send(w.payload.request(msg)); err != nil {
+			if err == io.EOF {
+				// Поток закрыт сервером, его статус возвращает CloseAndRecv
+				if _, rerr := stream.CloseAndRecv(); rerr != nil {
+					err = rerr
+				}
 			}
-			return i
+			log.Printf("Worker %d: Ошибка отправки AggregatePing: %v", workerID, err)
+			return WithPhase(PhaseSend, err)
 		}
-		p50 = latencies[idx(50)]
-		p90 = latencies[idx(90)]
-		p99 = latencies[idx(99)]
+		if i == 0 && it.Measured {
+			w.metrics.Record(MetricFirstSend, time.Since(start))
+		}
+		LogDebug("Worker %d: Отправлено AggregatePing: %s", workerID, msg)
 	}
 
-	log.Printf("AggregatePing: Всего запросов: %d, успешных: %d, неуспешных: %d", totalRequests, successCount, failCount)
-	log.Printf("AggregatePing: Latency p50: %s, p90: %s, p99: %s", p50, p90, p99)
+	sent := time.Now()
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		log.Printf("Worker %d: Ошибка получения AggregatePing ответа: %v", workerID, err)
+		return WithPhase(PhaseClose, err)
+	}
+	if end := time.Now(); it.Measured {
+		w.metrics.Record(MetricAckLatency, end.Sub(sent))
+		w.metrics.Record(MetricStreamDuration, end.Sub(start))
+	}
+	LogDebug("Worker %d: AggregatePing ответ: %s", workerID, resp.Message)
+	return nil
 }
+
+// pause ждёт d или отмены ctx; при отмене возвращает ошибку со статусом gRPC
+func pause(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+var _ MetricsWorkload = (*aggregatePing)(nil)
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// batchServer запоминает сообщения каждого потока AggregatePing
+type batchServer struct {
+	echoServer
+	mu      sync.Mutex
+	streams [][]string
+}
+
+func (s *batchServer) AggregatePing(stream pb.BenchmarkService_AggregatePingServer) error {
+	var msgs []string
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, req.Message)
+	}
+	s.mu.Lock()
+	s.streams = append(s.streams, msgs)
+	s.mu.Unlock()
+	return stream.SendAndClose(&pb.PingResponse{})
+}
+
+func TestAggregatePingBatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		batch Batch
+		want  []string // сообщения первого потока
+	}{
+		{"по умолчанию одно сообщение", Batch{}, []string{"aggregate ping #1"}},
+		{"пачка", Batch{Messages: 3}, []string{"aggregate ping #1.1", "aggregate ping #1.2", "aggregate ping #1.3"}},
+		{"пачка с паузой", Batch{Messages: 2, Gap: 20 * time.Millisecond}, []string{"aggregate ping #1.1", "aggregate ping #1.2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &batchServer{}
+			client := pb.NewBenchmarkServiceClient(benchConn(t, srv))
+			res, err := RunWorkload(AggregatePingWorkload(client, Payload{}, tt.batch),
+				LoadOptions{Scenario: ScenarioConstant, Concurrency: 1, Requests: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Success != 2 || len(srv.streams) != 2 {
+				t.Fatalf("успешных %d, потоков на сервере %d", res.Success, len(srv.streams))
+			}
+			if got := srv.streams[0]; len(got) != len(tt.want) || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("сообщения потока %q, ожидается %q", got, tt.want)
+			}
+			if first := res.Metrics[MetricFirstSend]; first.Count != 2 {
+				t.Errorf("%s: %d значений", MetricFirstSend, first.Count)
+			}
+			stream, ack := res.Metrics[MetricStreamDuration], res.Metrics[MetricAckLatency]
+			if stream.Count != 2 || ack.Count != 2 {
+				t.Fatalf("%s: %d значений, %s: %d", MetricStreamDuration, stream.Count, MetricAckLatency, ack.Count)
+			}
+			// Паузы между сообщениями входят в длительность потока, но не в ack_latency
+			if gaps := time.Duration(tt.batch.Messages-1) * tt.batch.Gap; gaps > 0 && (stream.Min < gaps || ack.Max >= gaps) {
+				t.Errorf("поток %v, ack %v при паузах %v", stream.Min, ack.Max, gaps)
+			}
+		})
+	}
+}
+
+func TestPause(t *testing.T) {
+	if err := pause(context.Background(), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pause(ctx, time.Hour); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("ошибка %v, ожидается DeadlineExceeded", err)
+	}
+}
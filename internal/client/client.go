@@ -1,12 +1,14 @@
 package client
 
 import (
-    pb "github.com/go-portfolio/go-grpc-benchmark/proto"
-    "google.golang.org/grpc"
+	"fmt"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+	"google.golang.org/grpc"
 )
 
 type BenchmarkClient struct {
-    pb.BenchmarkServiceClient
+	pb.BenchmarkServiceClient
 }
 
 type LoadScenario string
@@ -15,10 +17,22 @@ const (
 	ScenarioLight    LoadScenario = "light"
 	ScenarioPeak     LoadScenario = "peak"
 	ScenarioConstant LoadScenario = "constant"
+	// ScenarioOpenLoop — запросы уходят с фиксированной частотой TargetRPS
+	// независимо от времени ответа сервера
+	ScenarioOpenLoop LoadScenario = "open-loop"
 )
 
+// ParseScenario проверяет имя сценария нагрузки
+func ParseScenario(name string) (LoadScenario, error) {
+	switch s := LoadScenario(name); s {
+	case ScenarioLight, ScenarioPeak, ScenarioConstant, ScenarioOpenLoop:
+		return s, nil
+	}
+	return "", fmt.Errorf("неизвестный сценарий %q", name)
+}
+
 func NewBenchmarkClientWithConn(conn *grpc.ClientConn) *BenchmarkClient {
-    return &BenchmarkClient{
-        BenchmarkServiceClient: pb.NewBenchmarkServiceClient(conn),
-    }
+	return &BenchmarkClient{
+		BenchmarkServiceClient: pb.NewBenchmarkServiceClient(conn),
+	}
 }
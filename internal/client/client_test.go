@@ -0,0 +1,16 @@
+package client
+
+import "testing"
+
+func TestParseScenario(t *testing.T) {
+	for _, name := range []string{"light", "peak", "constant", "open-loop"} {
+		if s, err := ParseScenario(name); err != nil || string(s) != name {
+			t.Errorf("ParseScenario(%q) = %q, %v", name, s, err)
+		}
+	}
+	for _, name := range []string{"", "Peak", "closed-loop"} {
+		if _, err := ParseScenario(name); err == nil {
+			t.Errorf("ParseScenario(%q): нет ошибки", name)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ReadReport загружает отчёт, сохранённый с -format json
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &report, nil
+}
+
+// Thresholds — допустимое ухудшение метрик при сравнении прогонов
+type Thresholds struct {
+	RPS       float64 // допустимое падение RPS, %
+	Latency   float64 // допустимый рост перцентилей и среднего задержки, %
+	ErrorRate float64 // допустимый рост доли ошибок, процентные пункты
+	Alpha     float64 // уровень значимости статистического теста
+}
+
+// Verdict — вывод о значимости изменения метрики
+type Verdict string
+
+const (
+	VerdictFaster  Verdict = "faster"  // значимое улучшение
+	VerdictSlower  Verdict = "slower"  // значимое ухудшение
+	VerdictSame    Verdict = "same"    // нет значимой разницы
+	VerdictUnknown Verdict = "unknown" // данных для теста недостаточно
+)
+
+// minRunsForTest — сколько повторов нужно с каждой стороны, чтобы сравнивать
+// метрики прогонов U-критерием. Меньших выборок не хватает для значимости
+// даже при полном разделении: при 3 и 3 повторах p не бывает меньше 0.1.
+// Кроме того, минимально возможное p должно быть меньше Alpha (см.
+// perRunTestable), иначе задержки сравниваются по всем значениям гистограмм.
+const minRunsForTest = 4
+
+// MetricDelta — изменение одной метрики между базовыми и новыми прогонами.
+// При нескольких повторах Base и New — медианы по повторам.
+type MetricDelta struct {
+	Metric     string
+	Base       float64
+	New        float64
+	BaseCI     [2]float64 // 95% доверительный интервал
+	NewCI      [2]float64
+	DeltaPct   float64 // изменение в процентах от базового значения
+	P          float64 // p-value U-критерия Манна-Уитни, -1 — тест не проводился
+	Verdict    Verdict
+	Regression bool
+}
+
+// Comparison — сравнение одного бенчмарка в двух отчётах.
+// Base или New пуст, если бенчмарк есть только в одном из отчётов.
+type Comparison struct {
+	Key    string
+	Name   string
+	Base   []*Result // все повторы из базового отчёта
+	New    []*Result // все повторы из нового отчёта
+	Deltas []MetricDelta
+	PerRun bool // метрики сравнивались U-критерием по повторам
+}
+
+// Regressed сообщает, что хотя бы одна метрика ухудшилась сильнее порога
+func (c Comparison) Regressed() bool {
+	for _, d := range c.Deltas {
+		if d.Regression {
+			return true
+		}
+	}
+	return false
+}
+
+// ResultKey — ключ, по которому результаты сопоставляются между отчётами:
+// RPC, этап и цель плана и параметры нагрузки
+func ResultKey(r *Result) string {
+	p := r.Params
+	parts := []string{r.Method}
+	if r.Method == "" {
+		parts[0] = r.Name
+	}
+	if r.Stage != "" {
+		parts = append(parts, "stage="+r.Stage)
+	}
+	if r.Target != "" {
+		parts = append(parts, "target="+r.Target)
+	}
+	parts = append(parts, "scenario="+string(p.Scenario), "concurrency="+strconv.Itoa(p.Concurrency))
+	if p.Duration > 0 {
+		parts = append(parts, "duration="+p.Duration.String())
+	} else {
+		parts = append(parts, "requests="+strconv.Itoa(p.Requests))
+	}
+	if p.TargetRPS > 0 {
+		parts = append(parts, "rps="+strconv.FormatFloat(p.TargetRPS, 'f', -1, 64))
+	}
+	if p.Profile != "" {
+		parts = append(parts, "profile="+p.Profile)
+	}
+	if p.Mix != "" {
+		parts = append(parts, "mix="+p.Mix)
+	}
+	if p.StreamWindow > 0 {
+		parts = append(parts, "window="+strconv.Itoa(p.StreamWindow))
+	}
+	if p.BatchMessages > 0 {
+		parts = append(parts, "batch="+strconv.Itoa(p.BatchMessages))
+	}
+	if p.BatchGap > 0 {
+		parts = append(parts, "gap="+p.BatchGap.String())
+	}
+	if p.Push != nil {
+		parts = append(parts, "push="+p.Push.String())
+	}
+	if p.RequestSize != "" {
+		parts = append(parts, "request_size="+p.RequestSize)
+	}
+	if p.ResponseSize != "" {
+		parts = append(parts, "response_size="+p.ResponseSize)
+	}
+	if p.PayloadFill != "" {
+		parts = append(parts, "fill="+p.PayloadFill)
+	}
+	if p.Compression != "" {
+		parts = append(parts, "compression="+p.Compression)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Compare сопоставляет результаты двух отчётов и считает изменения метрик.
+// Повторы одного бенчмарка (с одинаковым ResultKey) объединяются в выборку.
+func Compare(base, cur *Report, th Thresholds) []Comparison {
+	var cmps []Comparison
+	index := map[string]int{}
+	group := func(r *Result, isBase bool) {
+		key := ResultKey(r)
+		i, ok := index[key]
+		if !ok {
+			i = len(cmps)
+			index[key] = i
+			cmps = append(cmps, Comparison{Key: key, Name: r.Name})
+		}
+		if isBase {
+			cmps[i].Base = append(cmps[i].Base, r)
+		} else {
+			cmps[i].New = append(cmps[i].New, r)
+		}
+	}
+	for _, r := range base.Results {
+		group(r, true)
+	}
+	for _, r := range cur.Results {
+		group(r, false)
+	}
+
+	for i := range cmps {
+		if len(cmps[i].Base) > 0 && len(cmps[i].New) > 0 {
+			cmps[i].Deltas, cmps[i].PerRun = compareRuns(cmps[i].Base, cmps[i].New, th)
+		}
+	}
+	return cmps
+}
+
+// metric — метрика результата, которая сравнивается между прогонами
+type metric struct {
+	name           string
+	value          func(r *Result) float64
+	higherIsBetter bool
+	latency        bool    // метрика распределения задержек
+	percentile     float64 // перцентиль для интервала по гистограмме, 0 — среднее
+	exceeded       func(d MetricDelta, th Thresholds) bool
+}
+
+func comparedMetrics(base *Result) []metric {
+	latencyExceeded := func(d MetricDelta, th Thresholds) bool { return d.Base > 0 && d.DeltaPct > th.Latency }
+	metrics := []metric{
+		{
+			name:           "rps",
+			value:          func(r *Result) float64 { return r.RPS },
+			higherIsBetter: true,
+			exceeded:       func(d MetricDelta, th Thresholds) bool { return -d.DeltaPct > th.RPS },
+		},
+		{
+			name:     "error_rate_%",
+			value:    errorRate,
+			exceeded: func(d MetricDelta, th Thresholds) bool { return d.New-d.Base > th.ErrorRate },
+		},
+		{
+			name:     "mean_ms",
+			value:    func(r *Result) float64 { return ms(r.Latency.Mean) },
+			latency:  true,
+			exceeded: latencyExceeded,
+		},
+	}
+	for _, pv := range base.Latency.Percentiles {
+		p := pv.P
+		metrics = append(metrics, metric{
+			name: "p" + strconv.FormatFloat(p, 'f', -1, 64) + "_ms",
+			value: func(r *Result) float64 {
+				v, _ := r.Latency.Percentile(p)
+				return ms(v)
+			},
+			latency:    true,
+			percentile: p,
+			exceeded:   latencyExceeded,
+		})
+	}
+	return metrics
+}
+
+// perRunTestable сообщает, может ли U-критерий по n1 и n2 повторам дать
+// значимый при alpha результат. Наименьшее двустороннее p без совпадений —
+// при полном разделении выборок: 2/C(n1+n2, n1).
+func perRunTestable(n1, n2 int, alpha float64) bool {
+	if n1 < minRunsForTest || n2 < minRunsForTest {
+		return false
+	}
+	return minMannWhitneyP(n1, n2) < alpha
+}
+
+// minMannWhitneyP — наименьшее двустороннее p точного U-критерия для n1 и n2
+func minMannWhitneyP(n1, n2 int) float64 {
+	combinations := 1.0
+	for i := 1; i <= n1; i++ {
+		combinations = combinations * float64(n2+i) / float64(i)
+	}
+	return math.Min(1, 2/combinations)
+}
+
+func compareRuns(base, cur []*Result, th Thresholds) (deltas []MetricDelta, perRun bool) {
+	perRun = perRunTestable(len(base), len(cur), th.Alpha)
+	baseHist, curHist := mergeHistograms(base), mergeHistograms(cur)
+	histP := -1.0
+	if !perRun && baseHist != nil && curHist != nil {
+		_, histP = MannWhitneyHistograms(baseHist, curHist)
+	}
+
+	for _, m := range comparedMetrics(base[0]) {
+		bv, nv := metricValues(base, m), metricValues(cur, m)
+		d := MetricDelta{Metric: m.name, Base: median(bv), New: median(nv), P: -1}
+		if d.Base != 0 {
+			d.DeltaPct = (d.New - d.Base) / d.Base * 100
+		}
+		d.BaseCI = metricCI(bv, baseHist, m)
+		d.NewCI = metricCI(nv, curHist, m)
+
+		switch {
+		case perRun:
+			_, d.P = MannWhitneyU(bv, nv)
+		case m.latency:
+			d.P = histP
+		}
+		d.Verdict = verdict(d, m.higherIsBetter, th.Alpha)
+
+		// Без статистического теста регрессия определяется только порогом,
+		// с тестом — ещё и значимостью ухудшения
+		d.Regression = m.exceeded(d, th) && (d.P < 0 || d.Verdict == VerdictSlower)
+		deltas = append(deltas, d)
+	}
+	return deltas, perRun
+}
+
+func verdict(d MetricDelta, higherIsBetter bool, alpha float64) Verdict {
+	if d.P < 0 {
+		return VerdictUnknown
+	}
+	if d.P >= alpha || d.New == d.Base {
+		return VerdictSame
+	}
+	if (d.New > d.Base) == higherIsBetter {
+		return VerdictFaster
+	}
+	return VerdictSlower
+}
+
+func metricValues(results []*Result, m metric) []float64 {
+	values := make([]float64, len(results))
+	for i, r := range results {
+		values[i] = m.value(r)
+	}
+	return values
+}
+
+// metricCI — 95% интервал метрики: bootstrap по повторам, а для одного
+// прогона — по гистограмме задержек
+func metricCI(values []float64, h *Histogram, m metric) [2]float64 {
+	var lo, hi float64
+	switch {
+	case len(values) >= 2:
+		lo, hi = bootstrapMedianCI(values)
+	case m.latency && h != nil && m.percentile > 0:
+		lo, hi = percentileCI(h, m.percentile)
+	case m.latency && h != nil:
+		lo, hi = meanCI(h)
+	default:
+		lo, hi = values[0], values[0]
+	}
+	return [2]float64{lo, hi}
+}
+
+// mergeHistograms объединяет гистограммы повторов; nil, если их нет в отчёте
+func mergeHistograms(results []*Result) *Histogram {
+	merged := NewHistogram()
+	for _, r := range results {
+		if r.Histogram == nil {
+			return nil
+		}
+		merged.Merge(r.Histogram)
+	}
+	return merged
+}
+
+// errorRate — доля неуспешных запросов в процентах
+func errorRate(r *Result) float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Failures) / float64(r.Requests) * 100
+}
+
+// deadlineRate — доля вызовов, превысивших дедлайн, в процентах
+func deadlineRate(r *Result) float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.DeadlineExceeded) / float64(r.Requests) * 100
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// verdictLabels — подписи вердиктов в текстовом выводе
+var verdictLabels = map[Verdict]string{
+	VerdictFaster:  "быстрее",
+	VerdictSlower:  "медленнее",
+	VerdictSame:    "~",
+	VerdictUnknown: "?",
+}
+
+// WriteComparison выводит таблицу сравнения; title — подпись пары отчётов.
+// Значения — медианы по повторам, в скобках — 95% доверительный интервал.
+func WriteComparison(w io.Writer, title string, cmps []Comparison) error {
+	fmt.Fprintf(w, "### %s\n", title)
+	for _, c := range cmps {
+		switch {
+		case len(c.New) == 0:
+			fmt.Fprintf(w, "\n%s: нет в новом отчёте\n", c.Key)
+			continue
+		case len(c.Base) == 0:
+			fmt.Fprintf(w, "\n%s: нет в базовом отчёте\n", c.Key)
+			continue
+		}
+
+		fmt.Fprintf(w, "\n%s [%s] повторов: %d → %d\n", c.Name, c.Key, len(c.Base), len(c.New))
+		if !c.PerRun && (len(c.Base) > 1 || len(c.New) > 1) {
+			fmt.Fprintf(w, "  повторов мало для U-критерия по повторам (наименьшее возможное p = %.3f): задержки сравниваются по гистограммам, остальные метрики — только по порогам\n",
+				minMannWhitneyP(len(c.Base), len(c.New)))
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  метрика\tбазовый\tновый\tизменение\tp\tвердикт\t")
+		for _, d := range c.Deltas {
+			p := "—"
+			if d.P >= 0 {
+				p = fmt.Sprintf("%.3f", d.P)
+			}
+			mark := ""
+			if d.Regression {
+				mark = "РЕГРЕССИЯ"
+			}
+			fmt.Fprintf(tw, "  %s\t%.3f [%.3f, %.3f]\t%.3f [%.3f, %.3f]\t%+.2f%%\t%s\t%s\t%s\n",
+				d.Metric, d.Base, d.BaseCI[0], d.BaseCI[1], d.New, d.NewCI[0], d.NewCI[1], d.DeltaPct, p, verdictLabels[d.Verdict], mark)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
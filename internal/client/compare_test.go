@@ -0,0 +1,161 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultKey(t *testing.T) {
+	base := RunParams{Scenario: ScenarioConstant, Concurrency: 10, Duration: 5 * time.Second}
+	tests := []struct {
+		name string
+		r    Result
+		want string
+	}{
+		{"метод", Result{Name: "Ping", Method: "/benchmark.BenchmarkService/Ping", Params: base},
+			"/benchmark.BenchmarkService/Ping scenario=constant concurrency=10 duration=5s"},
+		{"без метода — имя", Result{Name: "Mix", Params: base},
+			"Mix scenario=constant concurrency=10 duration=5s"},
+		{"этап и цель", Result{Name: "Ping", Stage: "s1", Target: "eu", Params: base},
+			"Ping stage=s1 target=eu scenario=constant concurrency=10 duration=5s"},
+		{"по числу запросов", Result{Name: "Ping", Params: RunParams{Scenario: ScenarioOpenLoop, Concurrency: 2, Requests: 100, TargetRPS: 50.5}},
+			"Ping scenario=open-loop concurrency=2 requests=100 rps=50.5"},
+		{"payload и сжатие", Result{Name: "Ping", Params: RunParams{Concurrency: 1, Duration: time.Second,
+			RequestSize: "1KiB", PayloadFill: "text", Compression: "gzip", StreamWindow: 4}},
+			"Ping scenario= concurrency=1 duration=1s window=4 request_size=1KiB fill=text compression=gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResultKey(&tt.r); got != tt.want {
+				t.Errorf("ResultKey = %q\nожидается   %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// runs возвращает n повторов бенчмарка на цели target с заданным RPS
+func runs(target string, rps ...float64) []*Result {
+	var results []*Result
+	for i, v := range rps {
+		results = append(results, &Result{
+			Name:     "Ping",
+			Target:   target,
+			Run:      i + 1,
+			Requests: 1000,
+			Success:  1000,
+			RPS:      v,
+			Params:   RunParams{Scenario: ScenarioConstant, Concurrency: 10, Duration: time.Second},
+		})
+	}
+	return results
+}
+
+// Повторы на разных целях сравниваются отдельно, а не одной выборкой
+func TestCompareTargets(t *testing.T) {
+	base := &Report{Results: append(runs("a", 100, 101, 102, 103, 104), runs("b", 1000, 1001, 1002, 1003, 1004)...)}
+	cur := &Report{Results: append(runs("a", 100, 101, 102, 103, 104), runs("b", 500, 501, 502, 503, 504)...)}
+	cmps := Compare(base, cur, Thresholds{RPS: 10, Latency: 10, ErrorRate: 1, Alpha: 0.05})
+	if len(cmps) != 2 {
+		t.Fatalf("сравнений %d, ожидается 2", len(cmps))
+	}
+	for _, c := range cmps {
+		if len(c.Base) != 5 || len(c.New) != 5 || !c.PerRun {
+			t.Fatalf("%s: повторов %d и %d, PerRun %v; ожидается по 5 и U-критерий", c.Key, len(c.Base), len(c.New), c.PerRun)
+		}
+		target := c.Base[0].Target
+		for _, r := range append(c.Base, c.New...) {
+			if r.Target != target {
+				t.Fatalf("%s: в выборке цели %s и %s", c.Key, target, r.Target)
+			}
+		}
+		rps := c.Deltas[0]
+		switch target {
+		case "a":
+			if rps.Verdict != VerdictSame || c.Regressed() {
+				t.Errorf("цель a: вердикт %s, регрессия %v; ожидается same без регрессии", rps.Verdict, c.Regressed())
+			}
+		case "b":
+			if rps.Base != 1002 || rps.New != 502 || rps.Verdict != VerdictSlower || !c.Regressed() {
+				t.Errorf("цель b: rps %g → %g, вердикт %s; ожидается 1002 → 502, slower", rps.Base, rps.New, rps.Verdict)
+			}
+		}
+	}
+}
+
+// histResult — один прогон с задержками from..to миллисекунд
+func histResult(rps float64, failures int64, from, to int) *Result {
+	h := NewHistogram()
+	for i := from; i <= to; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	return &Result{
+		Name: "Ping", Requests: 1000, Success: 1000 - failures, Failures: failures, RPS: rps,
+		Params:    RunParams{Scenario: ScenarioConstant, Concurrency: 1, Requests: 1000},
+		Latency:   Summarize(h),
+		Histogram: h,
+	}
+}
+
+// Одиночные прогоны: RPS и ошибки сравниваются только по порогам,
+// задержки — ещё и U-критерием по гистограммам
+func TestCompareThresholds(t *testing.T) {
+	th := Thresholds{RPS: 10, Latency: 10, ErrorRate: 1, Alpha: 0.05}
+	tests := []struct {
+		name string
+		cur  *Result
+		want map[string]bool // метрика → регрессия
+	}{
+		{"без изменений", histResult(1000, 0, 1, 100), map[string]bool{"rps": false, "error_rate_%": false, "p99_ms": false}},
+		{"RPS в пределах порога", histResult(950, 0, 1, 100), map[string]bool{"rps": false}},
+		{"RPS упал сильнее порога", histResult(800, 0, 1, 100), map[string]bool{"rps": true}},
+		{"ошибки выросли на 0.5 п.п.", histResult(1000, 5, 1, 100), map[string]bool{"error_rate_%": false}},
+		{"ошибки выросли на 2 п.п.", histResult(1000, 20, 1, 100), map[string]bool{"error_rate_%": true}},
+		{"задержки вдвое выше", histResult(1000, 0, 2, 200), map[string]bool{"mean_ms": true, "p50_ms": true, "p99_ms": true}},
+		{"задержки ниже", histResult(1000, 0, 1, 50), map[string]bool{"mean_ms": false, "p99_ms": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &Report{Results: []*Result{histResult(1000, 0, 1, 100)}}
+			cmps := Compare(base, &Report{Results: []*Result{tt.cur}}, th)
+			if len(cmps) != 1 || cmps[0].PerRun {
+				t.Fatalf("сравнений %d, ожидается одно без U-критерия по повторам", len(cmps))
+			}
+			deltas := map[string]MetricDelta{}
+			for _, d := range cmps[0].Deltas {
+				deltas[d.Metric] = d
+			}
+			for metric, regression := range tt.want {
+				d, ok := deltas[metric]
+				if !ok {
+					t.Fatalf("нет метрики %s", metric)
+				}
+				if d.Regression != regression {
+					t.Errorf("%s: %g → %g (%+.1f%%, p=%g): регрессия %v, ожидается %v", metric, d.Base, d.New, d.DeltaPct, d.P, d.Regression, regression)
+				}
+				if !strings.HasSuffix(metric, "_ms") && d.P != -1 {
+					t.Errorf("%s: p = %g без теста", metric, d.P)
+				}
+			}
+		})
+	}
+}
+
+// Бенчмарк, который есть только в одном отчёте, не сравнивается
+func TestCompareMissing(t *testing.T) {
+	onlyBase := histResult(1000, 0, 1, 10)
+	onlyBase.Name = "Stats"
+	base := &Report{Results: []*Result{histResult(1000, 0, 1, 10), onlyBase}}
+	cur := &Report{Results: []*Result{histResult(1000, 0, 1, 10)}}
+	cmps := Compare(base, cur, Thresholds{Alpha: 0.05})
+	if len(cmps) != 2 || cmps[1].Name != "Stats" || len(cmps[1].New) != 0 || cmps[1].Deltas != nil || cmps[1].Regressed() {
+		t.Fatalf("сравнения %+v", cmps)
+	}
+	var buf strings.Builder
+	if err := WriteComparison(&buf, "base → new", cmps); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "нет в новом отчёте") {
+		t.Errorf("в таблице нет отсутствующего бенчмарка:\n%s", buf.String())
+	}
+}
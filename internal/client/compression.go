@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/compression"
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+	"google.golang.org/grpc"
+)
+
+// Compressions — алгоритмы сжатия сообщений, которые можно сравнить
+var Compressions = compression.Names
+
+// compressedClient сжимает сообщения всех вызовов сервиса алгоритмом,
+// заданным опцией вызова; сервер отвечает тем же алгоритмом
+type compressedClient struct {
+	pb.BenchmarkServiceClient
+	opt grpc.CallOption
+}
+
+// withCompression возвращает клиент, сжимающий сообщения алгоритмом name;
+// пустое имя — клиент как есть
+func withCompression(client pb.BenchmarkServiceClient, name string) pb.BenchmarkServiceClient {
+	if name == "" {
+		return client
+	}
+	return &compressedClient{BenchmarkServiceClient: client, opt: grpc.UseCompressor(name)}
+}
+
+func (c *compressedClient) Ping(ctx context.Context, in *pb.PingRequest, opts ...grpc.CallOption) (*pb.PingResponse, error) {
+	return c.BenchmarkServiceClient.Ping(ctx, in, append(opts, c.opt)...)
+}
+
+func (c *compressedClient) Stats(ctx context.Context, in *pb.StatsRequest, opts ...grpc.CallOption) (*pb.StatsResponse, error) {
+	return c.BenchmarkServiceClient.Stats(ctx, in, append(opts, c.opt)...)
+}
+
+func (c *compressedClient) StreamPing(ctx context.Context, opts ...grpc.CallOption) (pb.BenchmarkService_StreamPingClient, error) {
+	return c.BenchmarkServiceClient.StreamPing(ctx, append(opts, c.opt)...)
+}
+
+func (c *compressedClient) PushNotifications(ctx context.Context, in *pb.PingRequest, opts ...grpc.CallOption) (pb.BenchmarkService_PushNotificationsClient, error) {
+	return c.BenchmarkServiceClient.PushNotifications(ctx, in, append(opts, c.opt)...)
+}
+
+func (c *compressedClient) AggregatePing(ctx context.Context, opts ...grpc.CallOption) (pb.BenchmarkService_AggregatePingClient, error) {
+	return c.BenchmarkServiceClient.AggregatePing(ctx, append(opts, c.opt)...)
+}
+
+// CompareCompression размножает этап на прогоны с каждым алгоритмом
+// сжатия из algorithms и каждым видом payload из fills. Пустой список
+// оставляет значение этапа.
+func CompareCompression(st Stage, algorithms, fills []string) ([]Stage, error) {
+	if len(algorithms) == 0 {
+		algorithms = []string{st.Load.Compression}
+	}
+	if len(fills) == 0 {
+		fills = []string{st.Load.Payload.Fill}
+	}
+	var stages []Stage
+	for _, fill := range fills {
+		if _, ok := payloadFills[fill]; !ok {
+			return nil, fmt.Errorf("неизвестное содержимое payload %q, доступны: random, text", fill)
+		}
+		for _, name := range algorithms {
+			if err := compression.Check(name); err != nil {
+				return nil, err
+			}
+			s := st
+			s.Load.Compression = name
+			s.Load.Payload.Fill = fill
+			s.Load.CompressionSeries = true
+			stages = append(stages, s)
+		}
+	}
+	return stages, nil
+}
+
+// writeCompressionTables выводит результаты сравнения алгоритмов сжатия
+// одной таблицей на бенчмарк. Трафик и процессорное время — в среднем
+// на вызов, сжатие — во сколько раз алгоритм уменьшил сообщения.
+func writeCompressionTables(w io.Writer, results []*Result) error {
+	type table struct {
+		title string
+		rows  []*Result
+	}
+	var tables []*table
+	index := map[string]*table{}
+	for _, r := range results {
+		if !r.Params.CompressionSeries {
+			continue
+		}
+		title := r.Name
+		if r.Stage != "" {
+			title += ", этап " + r.Stage
+		}
+		if r.Target != "" {
+			title += ", цель " + r.Target
+		}
+		if r.Run > 0 {
+			title += fmt.Sprintf(", повтор %d", r.Run)
+		}
+		title += fmt.Sprintf(", запрос %s, ответ %s", sizeLabel(r.Params.RequestSize), sizeLabel(r.Params.ResponseSize))
+		t, ok := index[title]
+		if !ok {
+			t = &table{title: title}
+			index[title] = t
+			tables = append(tables, t)
+		}
+		t.rows = append(t.rows, r)
+	}
+
+	for _, t := range tables {
+		fmt.Fprintf(w, "=== Сжатие: %s ===\n", t.title)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "алгоритм\tpayload\tRPS\tp50\tp99\tCPU/вызов\tотправлено/вызов\tполучено/вызов\tсжатие отпр.\tсжатие получ.")
+		for _, r := range t.rows {
+			calls := r.Requests + r.Discarded
+			cpu := "—" // у RPC смеси процессорное время не считается отдельно
+			if calls > 0 && r.CPUTime > 0 {
+				cpu = (r.CPUTime / time.Duration(calls)).String()
+			}
+			var tr Traffic
+			if r.Traffic != nil {
+				tr = *r.Traffic
+			}
+			sent, received := tr.perCall(calls)
+			p50, _ := r.Latency.Percentile(50)
+			p99, _ := r.Latency.Percentile(99)
+			fmt.Fprintf(tw, "%s\t%s\t%.2f\t%s\t%s\t%s\t%s\t%s\t×%.2f\t×%.2f\n",
+				compressionLabel(r.Params.Compression), fillLabel(r.Params.PayloadFill), r.RPS, p50, p99, cpu,
+				humanBytes(sent), humanBytes(received), ratio(tr.SentRaw, tr.SentWire), ratio(tr.ReceivedRaw, tr.ReceivedWire))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compressionLabel(name string) string {
+	if name == "" {
+		return compression.Identity
+	}
+	return name
+}
+
+func fillLabel(fill string) string {
+	if fill == "" {
+		return FillRandom
+	}
+	return fill
+}
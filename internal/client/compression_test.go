@@ -0,0 +1,75 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+func TestCompareCompression(t *testing.T) {
+	st := Stage{Name: "s", RPC: "Ping", Load: LoadOptions{Compression: "gzip", Payload: Payload{Fill: FillText}}}
+	tests := []struct {
+		name       string
+		algorithms []string
+		fills      []string
+		want       string // алгоритм/содержимое этапов серии; пусто — ошибка
+	}{
+		{"все алгоритмы", Compressions, nil, "identity/text gzip/text deflate/text"},
+		{"по видам payload", []string{"identity", "gzip"}, []string{FillRandom, FillText}, "identity/random gzip/random identity/text gzip/text"},
+		{"значения этапа", nil, nil, "gzip/text"},
+		{"неизвестный алгоритм", []string{"zstd"}, nil, ""},
+		{"неизвестное содержимое", nil, []string{"zeros"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stages, err := CompareCompression(st, tt.algorithms, tt.fills)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("этапов %d, ожидается ошибка", len(stages))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(stages))
+			for i, s := range stages {
+				got[i] = s.Load.Compression + "/" + s.Load.Payload.Fill
+				if !s.Load.CompressionSeries || s.Name != "s" || s.RPC != "Ping" {
+					t.Errorf("этап %d: %+v", i, s)
+				}
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("серия %v, ожидается %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Трафик прогона считается по методу; сжатие текстового payload
+// уменьшает байты на проводе относительно сообщений до сжатия
+func TestCompressionTraffic(t *testing.T) {
+	conn := benchConn(t, echoServer{}, grpc.WithStatsHandler(StatsHandler()))
+	client := pb.NewBenchmarkServiceClient(conn)
+	payload := Payload{Size: FixedSize(4096), Fill: FillText}
+	for _, name := range Compressions {
+		t.Run(name, func(t *testing.T) {
+			opts := LoadOptions{Scenario: ScenarioConstant, Concurrency: 1, Requests: 20, Payload: payload, Compression: name}
+			res, err := RunWorkload(PingWorkload(withCompression(client, name), payload), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr := res.Traffic
+			if tr == nil || tr.SentRaw < 20*4096 {
+				t.Fatalf("трафик %+v", tr)
+			}
+			compressed := ratio(tr.SentRaw, tr.SentWire) > 2
+			if compressed != (name != "identity") {
+				t.Errorf("отправлено %d байт до сжатия и %d на проводе", tr.SentRaw, tr.SentWire)
+			}
+		})
+	}
+}
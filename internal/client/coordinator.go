@@ -0,0 +1,366 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AgentStartLead — за сколько до общего старта координатор рассылает
+// задание: агентам нужно успеть разобрать его и подключиться к серверу
+const AgentStartLead = 2 * time.Second
+
+// clockProbes — сколько проб часов координатор отправляет агенту при
+// подключении; расхождение часов берётся по пробе с наименьшим RTT
+const clockProbes = 5
+
+// Coordinator раздаёт этапы подключившимся агентам и объединяет их
+// результаты в один. Агенты подключаются через CoordinatorService.Join;
+// этапы выполняются по одному, каждый — всеми агентами одновременно.
+type Coordinator struct {
+	pb.UnimplementedCoordinatorServiceServer
+
+	want   int
+	mu     sync.Mutex
+	agents []*remoteAgent
+	ready  chan struct{} // закрывается, когда подключились все want агентов
+	events chan agentEvent
+	lastID uint32
+}
+
+// remoteAgent — подключённый агент
+type remoteAgent struct {
+	name   string
+	offset time.Duration // часы агента минус часы координатора
+	stream pb.CoordinatorService_JoinServer
+	sendMu sync.Mutex
+	gone   error         // причина отключения агента
+	done   chan struct{} // закрывается, когда сессия агента завершилась
+}
+
+// agentEvent — сообщение агента
+type agentEvent struct {
+	agent *remoteAgent
+	msg   *pb.AgentMessage
+}
+
+// NewCoordinator создаёт координатор, который ждёт agents агентов
+func NewCoordinator(agents int) *Coordinator {
+	return &Coordinator{
+		want:   agents,
+		ready:  make(chan struct{}),
+		events: make(chan agentEvent, agents),
+	}
+}
+
+// Join регистрирует агента и держит его сессию до конца работы
+func (c *Coordinator) Join(stream pb.CoordinatorService_JoinServer) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	hello := msg.GetHello()
+	if hello == nil {
+		return status.Error(codes.InvalidArgument, "первым сообщением агента должен быть hello")
+	}
+	a := &remoteAgent{name: hello.Name, stream: stream, done: make(chan struct{})}
+	offset, rtt, err := a.measureClock()
+	if err != nil {
+		return err
+	}
+	a.offset = offset
+
+	c.mu.Lock()
+	if len(c.agents) >= c.want {
+		c.mu.Unlock()
+		return status.Errorf(codes.ResourceExhausted, "уже подключены все %d агентов", c.want)
+	}
+	c.agents = append(c.agents, a)
+	joined := len(c.agents)
+	if joined == c.want {
+		close(c.ready)
+	}
+	c.mu.Unlock()
+	// Run ждёт done вместо отдельного события об отключении: событие
+	// могло бы не поместиться в очередь и потеряться
+	defer close(a.done)
+	log.Printf("Агент %s подключился (%d из %d), расхождение часов: %s (RTT %s)", a.name, joined, c.want, a.offset, rtt)
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			gone := err
+			if err == io.EOF {
+				gone, err = errors.New("агент закрыл сессию"), nil
+			}
+			c.mu.Lock()
+			a.gone = gone
+			c.mu.Unlock()
+			return err
+		}
+		select {
+		case c.events <- agentEvent{agent: a, msg: msg}:
+		case <-stream.Context().Done():
+		}
+	}
+}
+
+// measureClock оценивает расхождение часов агента: время получения пробы
+// по часам агента сравнивается с серединой между отправкой пробы и
+// приходом ответа. Берётся проба с наименьшим RTT — у неё меньше всего
+// места для несимметричной задержки.
+func (a *remoteAgent) measureClock() (offset, rtt time.Duration, err error) {
+	for i := 0; i < clockProbes; i++ {
+		sent := time.Now()
+		probe := &pb.ClockProbe{UnixNano: sent.UnixNano()}
+		if err := a.send(&pb.CoordinatorMessage{Body: &pb.CoordinatorMessage_Clock{Clock: probe}}); err != nil {
+			return 0, 0, err
+		}
+		msg, err := a.stream.Recv()
+		if err != nil {
+			return 0, 0, err
+		}
+		reply := msg.GetClock()
+		if reply == nil || reply.ProbeUnixNano != probe.UnixNano {
+			return 0, 0, status.Error(codes.InvalidArgument, "ожидался ответ на пробу часов")
+		}
+		if d := time.Since(sent); i == 0 || d < rtt {
+			rtt = d
+			offset = time.Unix(0, reply.AgentUnixNano).Sub(sent.Add(d / 2))
+		}
+	}
+	return offset, rtt, nil
+}
+
+// Wait ждёт подключения всех агентов
+func (c *Coordinator) Wait(ctx context.Context) error {
+	select {
+	case <-c.ready:
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return fmt.Errorf("подключились %d из %d агентов: %v", len(c.agents), c.want, ctx.Err())
+	}
+}
+
+// Close сообщает агентам, что заданий больше не будет
+func (c *Coordinator) Close() {
+	c.mu.Lock()
+	agents := c.agents
+	c.mu.Unlock()
+	for _, a := range agents {
+		if err := a.send(&pb.CoordinatorMessage{Body: &pb.CoordinatorMessage_Done{Done: true}}); err != nil {
+			log.Printf("Агент %s: %v", a.name, err)
+		}
+	}
+}
+
+func (a *remoteAgent) send(msg *pb.CoordinatorMessage) error {
+	a.sendMu.Lock()
+	defer a.sendMu.Unlock()
+	return a.stream.Send(msg)
+}
+
+// Run выполняет этап st на сервере target силами всех агентов: каждый
+// получает свою долю нагрузки (LoadOptions.Slice) и стартует в один и тот
+// же момент. Снимки интервалов объединяются и выводятся по мере прихода,
+// результаты агентов — объединяются по бенчмаркам.
+func (c *Coordinator) Run(st Stage, target string) ([]*Result, error) {
+	c.mu.Lock()
+	agents := append([]*remoteAgent(nil), c.agents...)
+	c.lastID++
+	id := c.lastID
+	for _, a := range agents {
+		if a.gone != nil {
+			c.mu.Unlock()
+			return nil, fmt.Errorf("агент %s отключился: %v", a.name, a.gone)
+		}
+	}
+	c.mu.Unlock()
+	n := len(agents)
+	if n == 0 {
+		return nil, errors.New("нет подключённых агентов")
+	}
+	for i := range agents {
+		if _, err := st.Load.Slice(i, n); err != nil {
+			return nil, err
+		}
+	}
+	spec, err := st.Spec()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now().Add(AgentStartLead)
+	index := make(map[*remoteAgent]int, n)
+	for i, a := range agents {
+		index[a] = i
+		task := &pb.AgentTask{
+			Id:            id,
+			Index:         uint32(i),
+			Agents:        uint32(n),
+			Target:        target,
+			Stage:         data,
+			StartUnixNano: start.Add(a.offset).UnixNano(),
+		}
+		if err := a.send(&pb.CoordinatorMessage{Body: &pb.CoordinatorMessage_Task{Task: task}}); err != nil {
+			return nil, fmt.Errorf("агент %s: отправка задания: %v", a.name, err)
+		}
+	}
+	log.Printf("=== Распределённый прогон: %d агентов, старт в %s ===", n, start.Format("15:04:05.000"))
+
+	// Отключение агента ждём по его done: горутины переводят его в lost
+	lost := make(chan *remoteAgent, n)
+	stop := make(chan struct{})
+	defer close(stop)
+	for _, a := range agents {
+		go func() {
+			select {
+			case <-a.done:
+				lost <- a
+			case <-stop:
+			}
+		}()
+	}
+
+	intervals := map[string]map[int]*intervalMerge{}
+	parts := make([][]*Result, n)
+	pending := n
+	handle := func(ev agentEvent) error {
+		switch body := ev.msg.Body.(type) {
+		case *pb.AgentMessage_Interval:
+			r := body.Interval
+			if r.Task != id {
+				return nil
+			}
+			latency := NewHistogram()
+			if err := json.Unmarshal(r.Latency, latency); err != nil {
+				return fmt.Errorf("агент %s: снимок интервала: %v", ev.agent.name, err)
+			}
+			snap := Snapshot{
+				Start:     start.Add(time.Duration(r.OffsetNs)),
+				Offset:    time.Duration(r.OffsetNs),
+				Length:    time.Duration(r.LengthNs),
+				Success:   r.Success,
+				Failures:  r.Failures,
+				Discarded: r.Discarded,
+			}
+			byIndex := intervals[r.Name]
+			if byIndex == nil {
+				byIndex = map[int]*intervalMerge{}
+				intervals[r.Name] = byIndex
+			}
+			// Снимки агентов одного интервала сдвинуты на доли интервала
+			k := int((snap.Offset + st.Load.Interval/2) / st.Load.Interval)
+			m := byIndex[k]
+			if m == nil {
+				m = &intervalMerge{}
+				byIndex[k] = m
+			}
+			m.add(snap, latency)
+			if m.agents == n {
+				LogInfo("%s [%d агентов] %s", r.Name, n, formatSnapshot(m.result()))
+			}
+		case *pb.AgentMessage_Result:
+			r := body.Result
+			if r.Task != id {
+				return nil
+			}
+			if r.Error != "" {
+				return fmt.Errorf("агент %s: %s", ev.agent.name, r.Error)
+			}
+			results, err := decodeAgentResults(r)
+			if err != nil {
+				return fmt.Errorf("агент %s: %v", ev.agent.name, err)
+			}
+			if parts[index[ev.agent]] == nil {
+				pending--
+			}
+			parts[index[ev.agent]] = results
+		}
+		return nil
+	}
+	for pending > 0 {
+		select {
+		case ev := <-c.events:
+			if err := handle(ev); err != nil {
+				return nil, err
+			}
+		case a := <-lost:
+			// Сообщения, которые агент прислал до отключения, уже в очереди:
+			// его результат мог прийти перед закрытием сессии
+			for drained := false; !drained; {
+				select {
+				case ev := <-c.events:
+					if err := handle(ev); err != nil {
+						return nil, err
+					}
+				default:
+					drained = true
+				}
+			}
+			if parts[index[a]] == nil {
+				c.mu.Lock()
+				gone := a.gone
+				c.mu.Unlock()
+				return nil, fmt.Errorf("агент %s отключился: %v", a.name, gone)
+			}
+		}
+	}
+
+	merged := make([]*Result, len(parts[0]))
+	for j := range merged {
+		same := make([]*Result, n)
+		for i, rs := range parts {
+			if len(rs) != len(parts[0]) || rs[j].Name != parts[0][j].Name {
+				return nil, fmt.Errorf("агенты %s и %s вернули разные результаты", agents[0].name, agents[i].name)
+			}
+			same[i] = rs[j]
+		}
+		res := mergeResults(same)
+		res.Timeline = mergeTimeline(intervals[res.Name])
+		// Параметры — всего этапа, а не доли одного агента
+		params := newRunParams(st.Load)
+		params.Timeout, params.Mix = res.Params.Timeout, res.Params.Mix
+		res.Params = params
+		merged[j] = res
+	}
+	return merged, nil
+}
+
+// decodeAgentResults разбирает результаты агента вместе с гистограммами метрик
+func decodeAgentResults(r *pb.TaskResult) ([]*Result, error) {
+	var results []*Result
+	if err := json.Unmarshal(r.Results, &results); err != nil {
+		return nil, fmt.Errorf("результаты: %v", err)
+	}
+	if len(results) == 0 {
+		return nil, errors.New("пустые результаты")
+	}
+	var metrics []map[string]*Histogram
+	if len(r.Metrics) > 0 {
+		if err := json.Unmarshal(r.Metrics, &metrics); err != nil {
+			return nil, fmt.Errorf("метрики: %v", err)
+		}
+	}
+	for i, res := range results {
+		if i < len(metrics) {
+			res.metricHists = metrics[i]
+		}
+	}
+	return results, nil
+}
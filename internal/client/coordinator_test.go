@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// coordinatorStream поднимает в памяти координатор на одного агента и
+// открывает сессию Join, отвечая на пробы часов
+func coordinatorStream(t *testing.T) (*Coordinator, pb.CoordinatorService_JoinClient) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	c := NewCoordinator(1)
+	pb.RegisterCoordinatorServiceServer(srv, c)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	stream, err := pb.NewCoordinatorServiceClient(conn).Join(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hello := &pb.AgentMessage{Body: &pb.AgentMessage_Hello{Hello: &pb.AgentHello{Name: "a1"}}}
+	if err := stream.Send(hello); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < clockProbes; i++ {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		probe := msg.GetClock()
+		if probe == nil {
+			t.Fatalf("проба %d: получено %v", i, msg)
+		}
+		reply := &pb.ClockReply{ProbeUnixNano: probe.UnixNano, AgentUnixNano: time.Now().UnixNano()}
+		if err := stream.Send(&pb.AgentMessage{Body: &pb.AgentMessage_Clock{Clock: reply}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return c, stream
+}
+
+// Агент, закрывший сессию без ошибки посреди этапа, не должен оставлять
+// Run ждать вечно
+func TestCoordinatorAgentClosed(t *testing.T) {
+	c, stream := coordinatorStream(t)
+	st, err := BuildStage(StageSpec{RPC: "Ping", Concurrency: 1, Requests: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if _, err := stream.Recv(); err == nil {
+			stream.CloseSend()
+		}
+	}()
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.Run(st, "bufnet")
+		errc <- err
+	}()
+	select {
+	case err := <-errc:
+		if err == nil || !strings.Contains(err.Error(), "агент a1 отключился") {
+			t.Fatalf("ошибка %v, ожидается отключение агента", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run не заметил отключения агента")
+	}
+}
+
+// Результат, присланный перед закрытием сессии, не теряется
+func TestCoordinatorResultBeforeClose(t *testing.T) {
+	c, stream := coordinatorStream(t)
+	st, err := BuildStage(StageSpec{RPC: "Ping", Concurrency: 1, Requests: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		msg, err := stream.Recv()
+		if err != nil {
+			return
+		}
+		data, _ := json.Marshal([]*Result{{Name: "Ping", Success: 1}})
+		result := &pb.TaskResult{Task: msg.GetTask().Id, Results: data}
+		stream.Send(&pb.AgentMessage{Body: &pb.AgentMessage_Result{Result: result}})
+		stream.CloseSend()
+	}()
+
+	results, err := c.Run(st, "bufnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Success != 1 {
+		t.Fatalf("результаты %+v", results)
+	}
+}
+
+// Признаки точки серии Sweep и CompareCompression доходят до агента
+func TestStageSpecSeries(t *testing.T) {
+	st, err := BuildStage(StageSpec{RPC: "Ping", Concurrency: 1, Requests: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Load.Sweep, st.Load.CompressionSeries = "response", true
+	spec, err := st.Spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got StageSpec
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	built, err := BuildStage(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if built.Load.Sweep != "response" || !built.Load.CompressionSeries {
+		t.Errorf("Sweep %q, CompressionSeries %v; ожидается response, true", built.Load.Sweep, built.Load.CompressionSeries)
+	}
+}
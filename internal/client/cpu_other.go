@@ -0,0 +1,9 @@
+//go:build !unix
+
+package client
+
+import "time"
+
+// processCPUTime не поддерживается на этой платформе: процессорное
+// время в результаты не попадает
+func processCPUTime() time.Duration { return 0 }
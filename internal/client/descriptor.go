@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Описания произвольных сервисов для GenericWorkload берутся из
+// скомпилированного FileDescriptorSet или у сервера через gRPC reflection.
+
+// ParseMethodName разбирает полное имя метода: "pkg.Service/Method",
+// "/pkg.Service/Method" или "pkg.Service.Method"
+func ParseMethodName(name string) (service protoreflect.FullName, method protoreflect.Name, err error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		i = strings.LastIndex(name, ".")
+	}
+	if i <= 0 || i == len(name)-1 {
+		return "", "", fmt.Errorf("метод %q: ожидается полное имя вида pkg.Service/Method", name)
+	}
+	service, method = protoreflect.FullName(name[:i]), protoreflect.Name(name[i+1:])
+	if !service.IsValid() || !method.IsValid() {
+		return "", "", fmt.Errorf("метод %q: ожидается полное имя вида pkg.Service/Method", name)
+	}
+	return service, method, nil
+}
+
+// LoadProtoset читает FileDescriptorSet, собранный protoc с флагами
+// --include_imports --descriptor_set_out
+func LoadProtoset(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%s: не FileDescriptorSet: %v", path, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return files, nil
+}
+
+// ReflectFiles запрашивает у сервера через gRPC reflection (v1) файл
+// с описанием сервиса service и все файлы, от которых он зависит
+func ReflectFiles(ctx context.Context, conn grpc.ClientConnInterface, service protoreflect.FullName) (*protoregistry.Files, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reflection: %v", err)
+	}
+	defer stream.CloseSend()
+
+	protos := map[string]*descriptorpb.FileDescriptorProto{}
+	ask := func(req *rpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return status.Error(codes.Code(e.ErrorCode), e.ErrorMessage)
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fd); err != nil {
+				return err
+			}
+			protos[fd.GetName()] = fd
+		}
+		return nil
+	}
+
+	err = ask(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: string(service)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reflection: сервис %s: %v", service, err)
+	}
+	// Обычно сервер сразу присылает все зависимости, недостающие
+	// запрашиваются по имени файла
+	for {
+		missing := missingDependency(protos)
+		if missing == "" {
+			break
+		}
+		err := ask(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: missing},
+		})
+		if err == nil && protos[missing] == nil {
+			err = fmt.Errorf("сервер не вернул файл")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reflection: файл %s: %v", missing, err)
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range protos {
+		set.File = append(set.File, fd)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("reflection: %v", err)
+	}
+	return files, nil
+}
+
+// missingDependency — имя файла, от которого зависит один из protos,
+// но которого среди них нет; пусто, если все зависимости на месте
+func missingDependency(protos map[string]*descriptorpb.FileDescriptorProto) string {
+	for _, fd := range protos {
+		for _, dep := range fd.GetDependency() {
+			if protos[dep] == nil {
+				return dep
+			}
+		}
+	}
+	return ""
+}
+
+// FindMethod ищет метод name (см. ParseMethodName) среди files
+func FindMethod(files *protoregistry.Files, name string) (protoreflect.MethodDescriptor, error) {
+	service, method, err := ParseMethodName(name)
+	if err != nil {
+		return nil, err
+	}
+	d, err := files.FindDescriptorByName(service)
+	if err != nil {
+		return nil, fmt.Errorf("сервис %s не найден", service)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s — не сервис", service)
+	}
+	md := sd.Methods().ByName(method)
+	if md == nil {
+		names := make([]string, sd.Methods().Len())
+		for i := range names {
+			names[i] = string(sd.Methods().Get(i).Name())
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("у сервиса %s нет метода %s, доступны: %s", service, method, strings.Join(names, ", "))
+	}
+	return md, nil
+}
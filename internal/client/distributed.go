@@ -0,0 +1,279 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Spec возвращает этап в виде спецификации плана, из которой BuildStage
+// соберёт такой же этап в другом процессе. Серии Sweep и CompareCompression
+// к этому моменту уже развёрнуты: переносится только признак точки серии,
+// по которому результаты попадают в её таблицу.
+func (s Stage) Spec() (StageSpec, error) {
+	o := s.Load
+	spec := StageSpec{
+		Name:              s.Name,
+		RPC:               s.RPC,
+		Scenario:          string(o.Scenario),
+		Concurrency:       o.Concurrency,
+		RPS:               o.TargetRPS,
+		StreamWindow:      o.StreamWindow,
+		BatchMessages:     o.Batch.Messages,
+		Compression:       o.Compression,
+		SweepSide:         o.Sweep,
+		CompressionSeries: o.CompressionSeries,
+	}
+	if len(s.Mix) > 0 {
+		spec.RPC = ""
+		spec.Mix = make(map[string]float64, len(s.Mix))
+		for _, m := range s.Mix {
+			spec.Mix[m.RPC] = m.Weight
+		}
+	}
+	if o.Duration <= 0 {
+		spec.Requests = o.Requests
+	}
+	if o.Profile != nil {
+		if _, ok := o.Profile.(ProfileFunc); ok {
+			return StageSpec{}, errors.New("профиль-функцию нельзя передать агенту")
+		}
+		spec.Profile = fmt.Sprint(o.Profile)
+		if o.ProfileTarget != "" {
+			spec.Profile += ",target=" + string(o.ProfileTarget)
+		}
+	}
+	if len(o.RPCTimeouts) > 0 {
+		spec.RPCTimeouts = make(map[string]string, len(o.RPCTimeouts))
+		for name, d := range o.RPCTimeouts {
+			spec.RPCTimeouts[name] = d.String()
+		}
+	}
+	if o.Push.Count > 0 {
+		spec.Push = &PushSpec{Count: o.Push.Count, Interval: durationSpec(o.Push.Interval),
+			Distribution: o.Push.Distribution, PayloadSize: o.Push.PayloadSize}
+	}
+	payload := o.Payload
+	spec.Payload = &payload
+	spec.Duration = durationSpec(o.Duration)
+	spec.WarmUp = durationSpec(o.WarmUp)
+	spec.CoolDown = durationSpec(o.CoolDown)
+	spec.Timeout = durationSpec(o.Timeout)
+	spec.Interval = durationSpec(o.Interval)
+	spec.BatchGap = durationSpec(o.Batch.Gap)
+	return spec, nil
+}
+
+func durationSpec(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// BuildStage собирает этап из спецификации, полученной от Spec.
+// Незаданные поля остаются нулевыми, флаги процесса не используются.
+func BuildStage(spec StageSpec) (Stage, error) {
+	stages, err := (&Plan{Stages: []StageSpec{spec}}).Build(LoadOptions{})
+	if err != nil {
+		return Stage{}, err
+	}
+	if len(stages) != 1 {
+		return Stage{}, fmt.Errorf("спецификация развернулась в %d этапов", len(stages))
+	}
+	st := stages[0]
+	st.Load.Sweep, st.Load.CompressionSeries = spec.SweepSide, spec.CompressionSeries
+	return st, nil
+}
+
+// Slice — доля нагрузки агента index из agents. Воркеры и запросы делятся
+// поровну, остаток достаётся первым агентам; целевой RPS и профиль
+// уменьшаются в agents раз.
+func (o LoadOptions) Slice(index, agents int) (LoadOptions, error) {
+	if agents <= 1 {
+		return o, nil
+	}
+	share := func(n int) int {
+		s := n / agents
+		if index < n%agents {
+			s++
+		}
+		return s
+	}
+	if o.Concurrency < agents {
+		return o, fmt.Errorf("concurrency %d меньше числа агентов %d", o.Concurrency, agents)
+	}
+	o.Concurrency = share(o.Concurrency)
+	if o.Duration <= 0 {
+		if o.Requests < agents {
+			return o, fmt.Errorf("запросов %d меньше числа агентов %d", o.Requests, agents)
+		}
+		o.Requests = share(o.Requests)
+	}
+	o.TargetRPS /= float64(agents)
+	if o.Profile != nil {
+		o.Profile = scaledProfile{Profile: o.Profile, factor: 1 / float64(agents)}
+	}
+	return o, nil
+}
+
+// scaledProfile — профиль, умноженный на factor: доля профиля одного агента
+type scaledProfile struct {
+	Profile
+	factor float64
+}
+
+func (p scaledProfile) At(elapsed time.Duration) float64 { return p.Profile.At(elapsed) * p.factor }
+
+func (p scaledProfile) tail() (time.Duration, float64, bool) {
+	t, ok := p.Profile.(profileTail)
+	if !ok {
+		return 0, 0, false
+	}
+	end, final, ok := t.tail()
+	return end, final * p.factor, ok
+}
+
+func (p scaledProfile) String() string { return fmt.Sprintf("%v*%g", p.Profile, p.factor) }
+
+// intervalMerge собирает снимки интервалов всех агентов одного результата
+type intervalMerge struct {
+	snap    Snapshot
+	latency *Histogram
+	agents  int
+}
+
+// mergeSnapshot добавляет снимок агента к снимку интервала
+func (m *intervalMerge) add(s Snapshot, latency *Histogram) {
+	if m.latency == nil {
+		m.latency = NewHistogram()
+		m.snap.Start, m.snap.Offset = s.Start, s.Offset
+	}
+	if s.Length > m.snap.Length {
+		m.snap.Length = s.Length
+	}
+	m.snap.Success += s.Success
+	m.snap.Failures += s.Failures
+	m.snap.Discarded += s.Discarded
+	m.latency.Merge(latency)
+	m.agents++
+}
+
+// result — объединённый снимок интервала
+func (m *intervalMerge) result() Snapshot {
+	snap := m.snap
+	if snap.Length > 0 {
+		snap.RPS = float64(snap.Success) / snap.Length.Seconds()
+	}
+	if total := snap.Success + snap.Failures; total > 0 {
+		snap.ErrorRate = float64(snap.Failures) / float64(total) * 100
+	}
+	snap.Latency = Summarize(m.latency)
+	return snap
+}
+
+// mergeResults объединяет результаты одного бенчмарка, полученные агентами
+// одновременно: счётчики и гистограммы складываются, RPS агентов
+// суммируется, подписчики потоков нумеруются подряд.
+func mergeResults(parts []*Result) *Result {
+	res := *parts[0]
+	res.Errors = map[string]int64{}
+	res.Histogram = NewHistogram()
+	res.Timeline = nil
+	res.Agents = len(parts)
+	classes := errorClasses{}
+	metrics := map[string]*Histogram{}
+	var streams *StreamStats
+	var traffic *Traffic
+	var slowest slowCalls
+	for i, r := range parts {
+		if i > 0 {
+			res.Requests += r.Requests
+			res.Success += r.Success
+			res.Failures += r.Failures
+			res.DeadlineExceeded += r.DeadlineExceeded
+			res.Discarded += r.Discarded
+			res.RPS += r.RPS
+			res.IntendedRPS += r.IntendedRPS
+			res.CPUTime += r.CPUTime
+			if r.StartedAt.Before(res.StartedAt) {
+				res.StartedAt = r.StartedAt
+			}
+			if r.FinishedAt.After(res.FinishedAt) {
+				res.FinishedAt = r.FinishedAt
+			}
+			res.Elapsed = max(res.Elapsed, r.Elapsed)
+			res.Measured = max(res.Measured, r.Measured)
+		}
+		for code, n := range r.Errors {
+			res.Errors[code] += n
+		}
+		for _, c := range r.ErrorClasses {
+			c := c
+			classes.merge(errorClasses{{code: c.Code, phase: c.Phase}: &c})
+		}
+		if r.Histogram != nil {
+			res.Histogram.Merge(r.Histogram)
+		}
+		for name, h := range r.metricHists {
+			if metrics[name] == nil {
+				metrics[name] = NewHistogram()
+			}
+			metrics[name].Merge(h)
+		}
+		if s := r.Streams; s != nil {
+			if streams == nil {
+				streams = &StreamStats{}
+			}
+			streams.Messages += s.Messages
+			streams.MessagesPerSec += s.MessagesPerSec
+			streams.Completed += s.Completed
+			streams.Failed += s.Failed
+			streams.Broken += s.Broken
+			for _, sub := range s.Subscribers {
+				sub.Subscriber = len(streams.Subscribers)
+				streams.Subscribers = append(streams.Subscribers, sub)
+			}
+		}
+		if t := r.Traffic; t != nil {
+			if traffic == nil {
+				traffic = &Traffic{}
+			}
+			*traffic = traffic.add(*t)
+		}
+		slowest.merge(r.SlowestTraces)
+	}
+	if len(res.Errors) == 0 {
+		res.Errors = nil
+	}
+	res.ErrorClasses = classes.list()
+	if len(res.ErrorClasses) == 0 {
+		res.ErrorClasses = nil
+	}
+	res.Latency = Summarize(res.Histogram)
+	res.Streams, res.Traffic, res.SlowestTraces = streams, traffic, slowest
+	res.metricHists = nil
+	if len(metrics) > 0 {
+		res.metricHists = metrics
+		res.Metrics = make(map[string]LatencySummary, len(metrics))
+		for name, h := range metrics {
+			res.Metrics[name] = Summarize(h)
+		}
+	}
+	return &res
+}
+
+// mergeTimeline — объединённые снимки интервалов по порядку
+func mergeTimeline(intervals map[int]*intervalMerge) []Snapshot {
+	keys := make([]int, 0, len(intervals))
+	for k := range intervals {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	timeline := make([]Snapshot, len(keys))
+	for i, k := range keys {
+		timeline[i] = intervals[k].result()
+	}
+	return timeline
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Phase — этап вызова, на котором произошла ошибка
+type Phase string
+
+const (
+	PhaseDial  Phase = "dial"  // подключение, TLS и открытие потока
+	PhaseSend  Phase = "send"  // отправка сообщения
+	PhaseRecv  Phase = "recv"  // ожидание и получение ответа
+	PhaseClose Phase = "close" // закрытие отправки и итоговый ответ клиентского потока
+	PhaseCall  Phase = "call"  // унарный вызов целиком
+)
+
+// errorSamples — сколько разных сообщений об ошибке хранится для класса
+const errorSamples = 3
+
+// PhaseError — ошибка вызова с этапом, на котором она произошла.
+// Код gRPC берётся из исходной ошибки: status.Code видит его сквозь обёртку.
+type PhaseError struct {
+	Phase Phase
+	Err   error
+}
+
+func (e *PhaseError) Error() string { return string(e.Phase) + ": " + e.Err.Error() }
+func (e *PhaseError) Unwrap() error { return e.Err }
+
+// WithPhase помечает ошибку err этапом phase; nil остаётся nil.
+// Ошибка установки соединения относится к PhaseDial на любом этапе:
+// унарный вызов или первая отправка в поток получают её раньше, чем
+// хотя бы один байт запроса ушёл на сервер.
+func WithPhase(phase Phase, err error) error {
+	if err == nil {
+		return nil
+	}
+	if isDialError(err) {
+		phase = PhaseDial
+	}
+	return &PhaseError{Phase: phase, Err: err}
+}
+
+// dialErrorMarkers — фрагменты сообщений gRPC об ошибках резолвинга,
+// подключения и TLS, после которых вызов не мог начаться
+var dialErrorMarkers = []string{
+	"Error while dialing",
+	"authentication handshake failed",
+	"name resolver error",
+	"produced zero addresses",
+}
+
+// isDialError сообщает, что вызов не выполнен, потому что соединение с
+// сервером не установлено. gRPC возвращает такие ошибки с кодом
+// Unavailable, как и обрыв уже установленного соединения, поэтому они
+// различаются по сообщению.
+func isDialError(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.Unavailable {
+		return false
+	}
+	for _, marker := range dialErrorMarkers {
+		if strings.Contains(st.Message(), marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorClass — ошибки одного gRPC кода на одном этапе вызова
+type ErrorClass struct {
+	Code    string   `json:"code"`
+	Phase   Phase    `json:"phase,omitempty"` // пусто, если нагрузка не указала этап
+	Count   int64    `json:"count"`
+	Samples []string `json:"samples,omitempty"` // до трёх разных сообщений об ошибке
+}
+
+type errorKey struct {
+	code  string
+	phase Phase
+}
+
+// errorClasses — ошибки по коду и этапу с примерами сообщений
+type errorClasses map[errorKey]*ErrorClass
+
+// add учитывает ошибку err
+func (c errorClasses) add(err error) {
+	var phase Phase
+	var pe *PhaseError
+	if errors.As(err, &pe) {
+		phase, err = pe.Phase, pe.Err
+	}
+	st := status.Convert(err)
+	key := errorKey{code: st.Code().String(), phase: phase}
+	class, ok := c[key]
+	if !ok {
+		class = &ErrorClass{Code: key.code, Phase: phase}
+		c[key] = class
+	}
+	class.Count++
+	class.sample(st.Message())
+}
+
+// merge добавляет ошибки из other
+func (c errorClasses) merge(other errorClasses) {
+	for key, o := range other {
+		class, ok := c[key]
+		if !ok {
+			class = &ErrorClass{Code: o.Code, Phase: o.Phase}
+			c[key] = class
+		}
+		class.Count += o.Count
+		for _, msg := range o.Samples {
+			class.sample(msg)
+		}
+	}
+}
+
+// sample сохраняет сообщение, если оно новое и место для примеров есть
+func (e *ErrorClass) sample(msg string) {
+	if len(e.Samples) >= errorSamples {
+		return
+	}
+	for _, s := range e.Samples {
+		if s == msg {
+			return
+		}
+	}
+	e.Samples = append(e.Samples, msg)
+}
+
+// list возвращает классы по убыванию числа ошибок
+func (c errorClasses) list() []ErrorClass {
+	list := make([]ErrorClass, 0, len(c))
+	for _, class := range c {
+		list = append(list, *class)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		if list[i].Code != list[j].Code {
+			return list[i].Code < list[j].Code
+		}
+		return list[i].Phase < list[j].Phase
+	})
+	return list
+}
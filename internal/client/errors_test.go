@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+func TestWithPhaseDialErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		phase Phase
+		want  Phase
+	}{
+		{"подключение", status.Error(codes.Unavailable, `connection error: desc = "transport: Error while dialing: dial tcp 127.0.0.1:59999: connect: connection refused"`), PhaseCall, PhaseDial},
+		{"TLS", status.Error(codes.Unavailable, `connection error: desc = "transport: authentication handshake failed: tls: first record does not look like a TLS handshake"`), PhaseSend, PhaseDial},
+		{"резолвер", status.Error(codes.Unavailable, "name resolver error: produced zero addresses"), PhaseCall, PhaseDial},
+		{"обрыв соединения", status.Error(codes.Unavailable, "error reading from server: EOF"), PhaseRecv, PhaseRecv},
+		{"другой код", status.Error(codes.Internal, "transport: Error while dialing"), PhaseCall, PhaseCall},
+		{"не статус", errors.New("Error while dialing"), PhaseCall, PhaseCall},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pe *PhaseError
+			if !errors.As(WithPhase(tt.phase, tt.err), &pe) || pe.Phase != tt.want {
+				t.Errorf("этап %v, ожидается %s", pe, tt.want)
+			}
+		})
+	}
+}
+
+// Нагрузки, не сумевшие подключиться к серверу, одинаково относят
+// ошибку к этапу dial
+func TestWorkloadsDialPhase(t *testing.T) {
+	lis := bufconn.Listen(1 << 10)
+	lis.Close()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := pb.NewBenchmarkServiceClient(conn)
+
+	workloads := []Workload{
+		PingWorkload(c, Payload{}),
+		StreamPingWorkload(c, Payload{}),
+		LongStreamPingWorkload(c, Payload{}, 1, 1),
+		AggregatePingWorkload(c, Payload{}, Batch{Messages: 2}),
+		PushNotificationsWorkload(c, Payload{}, PushStream{}),
+	}
+	for _, w := range workloads {
+		t.Run(w.Name(), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := w.Setup(ctx); err != nil {
+				t.Fatal(err)
+			}
+			defer w.Teardown(ctx)
+			err := w.Execute(ctx, Iteration{})
+			classes := errorClasses{}
+			classes.add(err)
+			list := classes.list()
+			if len(list) != 1 || list[0].Code != codes.Unavailable.String() || list[0].Phase != PhaseDial {
+				t.Errorf("ошибка %v классифицирована как %+v, ожидается Unavailable/dial", err, list)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	mrand "math/rand"
+	"strings"
+	"text/template"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GenericCall — метод произвольного сервиса и шаблон его запроса
+type GenericCall struct {
+	Method   protoreflect.MethodDescriptor
+	Template *RequestTemplate
+}
+
+// FullMethod — имя метода в виде "/pkg.Service/Method"
+func (c GenericCall) FullMethod() string {
+	return "/" + string(c.Method.Parent().FullName()) + "/" + string(c.Method.Name())
+}
+
+// Generic прогоняет нагрузку вызовами метода call через conn
+func Generic(conn grpc.ClientConnInterface, call GenericCall, opts LoadOptions) (*Result, error) {
+	log.Printf("=== %s ===", call.FullMethod())
+	return RunWorkload(GenericWorkload(conn, call, opts), opts)
+}
+
+// GenericWorkload — вызовы произвольного метода без сгенерированных
+// стабов: запросы собираются из call.Template, ответы разбираются по
+// дескриптору и отбрасываются. Итерация зависит от типа метода:
+//   - унарный — один вызов;
+//   - серверный поток — запрос и чтение всех ответов до конца потока;
+//   - клиентский поток — opts.Batch.Messages запросов с паузой
+//     opts.Batch.Gap и итоговый ответ;
+//   - двунаправленный поток — так же отправляются запросы, затем
+//     отправка закрывается и читаются все ответы.
+//
+// Для потоков с ответами сервера собирается MetricFirstMessage.
+func GenericWorkload(conn grpc.ClientConnInterface, call GenericCall, opts LoadOptions) Workload {
+	w := &genericWorkload{
+		conn:   conn,
+		call:   call,
+		method: call.FullMethod(),
+		batch:  opts.Batch,
+		desc: grpc.StreamDesc{
+			StreamName:    string(call.Method.Name()),
+			ClientStreams: call.Method.IsStreamingClient(),
+			ServerStreams: call.Method.IsStreamingServer(),
+		},
+	}
+	if w.batch.Messages < 1 || !w.desc.ClientStreams {
+		w.batch.Messages = 1
+	}
+	if opts.Compression != "" {
+		w.callOpts = append(w.callOpts, grpc.UseCompressor(opts.Compression))
+	}
+	return w
+}
+
+type genericWorkload struct {
+	conn     grpc.ClientConnInterface
+	call     GenericCall
+	method   string
+	desc     grpc.StreamDesc
+	batch    Batch
+	callOpts []grpc.CallOption
+	metrics  MetricSet
+}
+
+func (w *genericWorkload) Name() string   { return string(w.call.Method.Name()) }
+func (w *genericWorkload) Method() string { return w.method }
+
+func (w *genericWorkload) Setup(context.Context) error    { return nil }
+func (w *genericWorkload) Teardown(context.Context) error { return nil }
+
+func (w *genericWorkload) Metrics() map[string]*Histogram {
+	return w.metrics.Histograms()
+}
+
+func (w *genericWorkload) Execute(ctx context.Context, it Iteration) error {
+	if !w.desc.ClientStreams && !w.desc.ServerStreams {
+		req, err := w.call.Template.Message(it, 0)
+		if err != nil {
+			return WithPhase(PhaseCall, err)
+		}
+		resp := dynamicpb.NewMessage(w.call.Method.Output())
+		return WithPhase(PhaseCall, w.conn.Invoke(ctx, w.method, req, resp, w.callOpts...))
+	}
+
+	start := time.Now()
+	stream, err := w.conn.NewStream(ctx, &w.desc, w.method, w.callOpts...)
+	if err != nil {
+		return WithPhase(PhaseDial, err)
+	}
+	resp := dynamicpb.NewMessage(w.call.Method.Output())
+	for i := 0; i < w.batch.Messages; i++ {
+		if i > 0 && w.batch.Gap > 0 {
+			if err := pause(ctx, w.batch.Gap); err != nil {
+				return WithPhase(PhaseSend, err)
+			}
+		}
+		req, err := w.call.Template.Message(it, i)
+		if err != nil {
+			return WithPhase(PhaseSend, err)
+		}
+		if err := stream.SendMsg(req); err != nil {
+			if err == io.EOF {
+				// Поток закрыт сервером, его статус возвращает RecvMsg
+				if rerr := stream.RecvMsg(resp); rerr != nil && rerr != io.EOF {
+					err = rerr
+				}
+			}
+			return WithPhase(PhaseSend, err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return WithPhase(PhaseClose, err)
+	}
+	if !w.desc.ServerStreams {
+		return WithPhase(PhaseClose, stream.RecvMsg(resp))
+	}
+	for n := 0; ; n++ {
+		err := stream.RecvMsg(resp)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return WithPhase(PhaseRecv, err)
+		}
+		if n == 0 && it.Measured {
+			w.metrics.Record(MetricFirstMessage, time.Since(start))
+		}
+	}
+}
+
+// RequestTemplate — запрос в JSON (protojson) с подстановками text/template:
+//
+//	{{.Worker}}, {{.Seq}}   — номер воркера и сквозной номер итерации
+//	{{.Message}}            — номер сообщения в клиентском потоке
+//	{{randInt 1 100}}       — случайное целое из диапазона
+//	{{randString 16}}       — случайная строка из латинских букв и цифр
+//	{{uuid}}, {{now}}       — случайный UUID и текущее время в RFC 3339
+//
+// Запрос без подстановок разбирается один раз и отправляется как есть.
+type RequestTemplate struct {
+	desc   protoreflect.MessageDescriptor
+	tmpl   *template.Template // nil — запрос без подстановок
+	static proto.Message
+}
+
+type templateData struct {
+	Worker, Seq, Message int
+}
+
+var templateFuncs = template.FuncMap{
+	"randInt": func(min, max int) (int, error) {
+		if max < min {
+			return 0, fmt.Errorf("randInt: max меньше min")
+		}
+		return min + mrand.Intn(max-min+1), nil
+	},
+	"randString": func(n int) string {
+		const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = letters[mrand.Intn(len(letters))]
+		}
+		return string(b)
+	},
+	"uuid": func() string {
+		var b [16]byte
+		rand.Read(b[:])
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		h := hex.EncodeToString(b[:])
+		return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	},
+	"now": func() string { return time.Now().Format(time.RFC3339Nano) },
+}
+
+// ParseRequestTemplate разбирает шаблон запроса с типом desc. Шаблон
+// сразу пробно подставляется, чтобы ошибки в JSON нашлись до прогона.
+func ParseRequestTemplate(text string, desc protoreflect.MessageDescriptor) (*RequestTemplate, error) {
+	t := &RequestTemplate{desc: desc}
+	if !strings.Contains(text, "{{") {
+		msg, err := t.unmarshal([]byte(text))
+		if err != nil {
+			return nil, err
+		}
+		t.static = msg
+		return t, nil
+	}
+	tmpl, err := template.New("request").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("шаблон запроса: %v", err)
+	}
+	t.tmpl = tmpl
+	if _, err := t.Message(Iteration{}, 0); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Message собирает сообщение msg клиентского потока итерации it
+func (t *RequestTemplate) Message(it Iteration, msg int) (proto.Message, error) {
+	if t.tmpl == nil {
+		return t.static, nil
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, templateData{Worker: it.Worker, Seq: it.Seq, Message: msg}); err != nil {
+		return nil, fmt.Errorf("шаблон запроса: %v", err)
+	}
+	return t.unmarshal(buf.Bytes())
+}
+
+func (t *RequestTemplate) unmarshal(data []byte) (proto.Message, error) {
+	msg := dynamicpb.NewMessage(t.desc)
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("запрос %s: %v", t.desc.FullName(), err)
+	}
+	return msg, nil
+}
@@ -0,0 +1,407 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/status"
+)
+
+// Раскладка бакетов по схеме HDR Histogram: значения в микросекундах,
+// точность — 3 значащие цифры (относительная ошибка не больше 0.1%).
+// Каждый следующий бакет вдвое шире предыдущего и делится на
+// histSubBucketHalfCount линейных под-бакетов.
+const (
+	histUnit                        = time.Microsecond
+	histSignificantFigures          = 3
+	histSubBucketCountMagnitude     = 11 // ceil(log2(2 * 10^histSignificantFigures))
+	histSubBucketHalfCountMagnitude = histSubBucketCountMagnitude - 1
+	histSubBucketCount              = 1 << histSubBucketCountMagnitude
+	histSubBucketHalfCount          = histSubBucketCount / 2
+	histSubBucketMask               = histSubBucketCount - 1
+)
+
+// Histogram — гистограмма задержек с высоким динамическим диапазоном.
+// Histogram не потокобезопасна: каждый воркер пишет в свою гистограмму
+// без блокировок, а в конце прогона они объединяются через Merge.
+// Счётчики бакетов выделяются по мере роста записанных значений.
+type Histogram struct {
+	counts []int64
+	count  int64
+	min    time.Duration
+	max    time.Duration
+	sum    float64 // сумма значений в наносекундах
+	sumSq  float64 // сумма квадратов для стандартного отклонения
+}
+
+// NewHistogram создаёт пустую гистограмму
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// Record добавляет одно значение задержки
+func (h *Histogram) Record(d time.Duration) {
+	h.recordN(d, 1)
+}
+
+func (h *Histogram) recordN(d time.Duration, n int64) {
+	if d < 0 {
+		d = 0
+	}
+	idx := histCountsIndex(int64(d / histUnit))
+	if idx >= len(h.counts) {
+		h.grow(idx + 1)
+	}
+	h.counts[idx] += n
+
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count += n
+	ns := float64(d)
+	h.sum += ns * float64(n)
+	h.sumSq += ns * ns * float64(n)
+}
+
+func (h *Histogram) grow(size int) {
+	if c := 2 * len(h.counts); c > size {
+		size = c
+	}
+	counts := make([]int64, size)
+	copy(counts, h.counts)
+	h.counts = counts
+}
+
+// Merge добавляет к гистограмме все значения из other
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.count == 0 {
+		return
+	}
+	if len(other.counts) > len(h.counts) {
+		h.grow(len(other.counts))
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+	h.sumSq += other.sumSq
+}
+
+// Count — количество записанных значений
+func (h *Histogram) Count() int64 { return h.count }
+
+// Min — минимальное записанное значение
+func (h *Histogram) Min() time.Duration { return h.min }
+
+// Max — максимальное записанное значение
+func (h *Histogram) Max() time.Duration { return h.max }
+
+// Mean — среднее значение
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / float64(h.count))
+}
+
+// StdDev — стандартное отклонение
+func (h *Histogram) StdDev() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	mean := h.sum / float64(h.count)
+	variance := h.sumSq/float64(h.count) - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+	return time.Duration(math.Sqrt(variance))
+}
+
+// Percentile возвращает значение перцентиля p (от 0 до 100), например 99.9.
+// Результат — верхняя граница бакета, но не больше максимума.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	if p >= 100 {
+		return h.max
+	}
+	if p < 0 {
+		p = 0
+	}
+	target := int64(math.Ceil(p / 100 * float64(h.count)))
+	if target < 1 {
+		target = 1
+	}
+	return h.valueAtRank(target)
+}
+
+// countAtOrBelow — количество значений не больше d с точностью до бакета
+func (h *Histogram) countAtOrBelow(d time.Duration) int64 {
+	limit := int64(d / histUnit)
+	var n int64
+	for i, c := range h.counts {
+		if histValueFromIndex(i) > limit {
+			break
+		}
+		n += c
+	}
+	return n
+}
+
+// valueAtRank возвращает значение с порядковым номером rank (начиная с 1)
+func (h *Histogram) valueAtRank(rank int64) time.Duration {
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			v := time.Duration(histHighestEquivalent(histValueFromIndex(i))) * histUnit
+			if v > h.max {
+				v = h.max
+			}
+			if v < h.min {
+				v = h.min
+			}
+			return v
+		}
+	}
+	return h.max
+}
+
+// histCountsIndex — индекс счётчика для значения v (в единицах histUnit)
+func histCountsIndex(v int64) int {
+	bucket := 64 - bits.LeadingZeros64(uint64(v)|histSubBucketMask) - histSubBucketCountMagnitude
+	subBucket := int(v >> uint(bucket))
+	return (bucket+1)<<histSubBucketHalfCountMagnitude + subBucket - histSubBucketHalfCount
+}
+
+// histValueFromIndex — нижняя граница значений, попадающих в счётчик idx
+func histValueFromIndex(idx int) int64 {
+	bucket := idx>>histSubBucketHalfCountMagnitude - 1
+	subBucket := idx&(histSubBucketHalfCount-1) + histSubBucketHalfCount
+	if bucket < 0 {
+		subBucket -= histSubBucketHalfCount
+		bucket = 0
+	}
+	return int64(subBucket) << uint(bucket)
+}
+
+// histHighestEquivalent — верхняя граница бакета, в который попадает v
+func histHighestEquivalent(v int64) int64 {
+	bucket := 64 - bits.LeadingZeros64(uint64(v)|histSubBucketMask) - histSubBucketCountMagnitude
+	lowest := v >> uint(bucket) << uint(bucket)
+	return lowest + 1<<uint(bucket) - 1
+}
+
+// histogramJSON — сериализованная форма гистограммы. Бакеты хранятся
+// разреженно в виде пар [нижняя граница в мкс, количество], поэтому
+// гистограммы из разных прогонов и процессов можно загрузить и объединить.
+type histogramJSON struct {
+	Unit               string     `json:"unit"`
+	SignificantFigures int        `json:"significant_figures"`
+	Count              int64      `json:"count"`
+	MinNs              int64      `json:"min_ns"`
+	MaxNs              int64      `json:"max_ns"`
+	SumNs              float64    `json:"sum_ns"`
+	SumSqNs            float64    `json:"sum_sq_ns"`
+	Buckets            [][2]int64 `json:"buckets"`
+}
+
+// MarshalJSON сериализует гистограмму
+func (h *Histogram) MarshalJSON() ([]byte, error) {
+	out := histogramJSON{
+		Unit:               "us",
+		SignificantFigures: histSignificantFigures,
+		Count:              h.count,
+		MinNs:              int64(h.min),
+		MaxNs:              int64(h.max),
+		SumNs:              h.sum,
+		SumSqNs:            h.sumSq,
+		Buckets:            [][2]int64{},
+	}
+	for i, c := range h.counts {
+		if c != 0 {
+			out.Buckets = append(out.Buckets, [2]int64{histValueFromIndex(i), c})
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON восстанавливает гистограмму из MarshalJSON
+func (h *Histogram) UnmarshalJSON(data []byte) error {
+	var in histogramJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Unit != "us" || in.SignificantFigures != histSignificantFigures {
+		return fmt.Errorf("несовместимая гистограмма: unit=%q, significant_figures=%d", in.Unit, in.SignificantFigures)
+	}
+	*h = Histogram{}
+	for _, b := range in.Buckets {
+		idx := histCountsIndex(b[0])
+		if idx >= len(h.counts) {
+			h.grow(idx + 1)
+		}
+		h.counts[idx] += b[1]
+	}
+	h.count = in.Count
+	h.min = time.Duration(in.MinNs)
+	h.max = time.Duration(in.MaxNs)
+	h.sum = in.SumNs
+	h.sumSq = in.SumSqNs
+	return nil
+}
+
+// workerStats — счётчики и гистограмма одного воркера.
+// Каждый воркер пишет только в свой элемент, поэтому блокировки не нужны.
+type workerStats struct {
+	success  int64
+	fail     int64
+	errors   map[string]int64 // ошибки по gRPC коду
+	classes  errorClasses     // ошибки по коду и этапу вызова
+	latency  *Histogram
+	slowest  slowCalls      // самые медленные вызовы с трассой
+	measured bool           // false — статистика разогрева и остывания
+	interval *intervalStats // счётчики текущего интервала; nil, если снимки не собираются
+}
+
+// record учитывает итог вызова r: ошибку или задержку от r.intended
+// за вычетом r.excluded. Успешные вызовы из выборки трассировки (sc)
+// претендуют на место среди самых медленных.
+func (s *workerStats) record(r request, err error, sc trace.SpanContext) {
+	if err != nil {
+		s.failed(err)
+		return
+	}
+	latency := time.Since(r.intended) - r.excluded
+	s.succeeded(latency)
+	if sc.IsSampled() {
+		s.slowest.add(TracedCall{TraceID: sc.TraceID().String(), Start: r.intended, Latency: latency, Worker: r.worker})
+	}
+}
+
+// succeeded учитывает успешный вызов с задержкой latency
+func (s *workerStats) succeeded(latency time.Duration) {
+	s.success++
+	s.latency.Record(latency)
+	if s.interval != nil {
+		s.interval.record(latency, nil, s.measured)
+	}
+}
+
+// failed учитывает неуспешный вызов
+func (s *workerStats) failed(err error) {
+	s.fail++
+	if s.errors == nil {
+		s.errors = map[string]int64{}
+	}
+	s.errors[status.Code(err).String()]++
+	if s.classes == nil {
+		s.classes = errorClasses{}
+	}
+	s.classes.add(err)
+	if s.interval != nil {
+		s.interval.record(0, err, s.measured)
+	}
+}
+
+// workerStatsSet — статистика всех воркеров. Запросы фаз разогрева
+// и остывания пишутся отдельно и в итоговые метрики не попадают.
+// При opts.Interval > 0 дополнительно собираются снимки по интервалам.
+type workerStatsSet struct {
+	measured  []workerStats
+	discarded []workerStats
+	timeline  *timeline
+}
+
+func newWorkerStats(name string, opts LoadOptions) *workerStatsSet {
+	n := opts.Concurrency
+	s := &workerStatsSet{
+		measured:  make([]workerStats, n),
+		discarded: make([]workerStats, n),
+	}
+	if opts.Interval > 0 {
+		s.timeline = newTimeline(name, opts.Interval, n, opts.OnInterval)
+	}
+	for i := 0; i < n; i++ {
+		s.measured[i].latency = NewHistogram()
+		s.measured[i].measured = true
+		s.discarded[i].latency = NewHistogram()
+		if s.timeline != nil {
+			s.measured[i].interval = s.timeline.workers[i]
+			s.discarded[i].interval = s.timeline.workers[i]
+		}
+	}
+	return s
+}
+
+// of возвращает статистику воркера, в которую пишется запрос r
+func (s *workerStatsSet) of(r request) *workerStats {
+	if r.measured {
+		return &s.measured[r.worker]
+	}
+	return &s.discarded[r.worker]
+}
+
+// counts — число измеряемых и отброшенных вызовов
+func (s *workerStatsSet) counts() (measured, discarded int64) {
+	for i := range s.measured {
+		measured += s.measured[i].success + s.measured[i].fail
+		discarded += s.discarded[i].success + s.discarded[i].fail
+	}
+	return measured, discarded
+}
+
+// snapshots останавливает сбор снимков и возвращает их
+func (s *workerStatsSet) snapshots() []Snapshot {
+	if s.timeline == nil {
+		return nil
+	}
+	return s.timeline.finish()
+}
+
+// slowest — самые медленные измеряемые вызовы с трассой по всем воркерам
+func (s *workerStatsSet) slowest() []TracedCall {
+	var all slowCalls
+	for _, w := range s.measured {
+		all.merge(w.slowest)
+	}
+	return all
+}
+
+// merge объединяет статистику измеряемых запросов всех воркеров
+// после завершения прогона
+func (s *workerStatsSet) merge() (success, fail int64, errs map[string]int64, classes []ErrorClass, latency *Histogram) {
+	latency = NewHistogram()
+	all := errorClasses{}
+	for _, w := range s.measured {
+		success += w.success
+		fail += w.fail
+		for code, n := range w.errors {
+			if errs == nil {
+				errs = map[string]int64{}
+			}
+			errs[code] += n
+		}
+		all.merge(w.classes)
+		latency.Merge(w.latency)
+	}
+	if len(all) > 0 {
+		classes = all.list()
+	}
+	return success, fail, errs, classes, latency
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestHistIndexRoundTrip(t *testing.T) {
+	values := []int64{
+		0, 1, 2,
+		histSubBucketCount - 1, histSubBucketCount, histSubBucketCount + 1,
+		2*histSubBucketCount - 1, 2 * histSubBucketCount, 2*histSubBucketCount + 1,
+		1<<20 - 1, 1 << 20, 1<<20 + 1,
+		int64(time.Hour / histUnit),
+	}
+	for _, v := range values {
+		idx := histCountsIndex(v)
+		lo := histValueFromIndex(idx)
+		hi := histHighestEquivalent(v)
+		if lo > v || v > hi {
+			t.Errorf("v=%d: бакет [%d, %d] не содержит значение", v, lo, hi)
+		}
+		if got := histCountsIndex(lo); got != idx {
+			t.Errorf("v=%d: нижняя граница %d в бакете %d, ожидается %d", v, lo, got, idx)
+		}
+		if got := histCountsIndex(hi); got != idx {
+			t.Errorf("v=%d: верхняя граница %d в бакете %d, ожидается %d", v, hi, got, idx)
+		}
+		if got := histCountsIndex(hi + 1); got != idx+1 {
+			t.Errorf("v=%d: значение %d после бакета в бакете %d, ожидается %d", v, hi+1, got, idx+1)
+		}
+		if v < histSubBucketCount && lo != hi {
+			t.Errorf("v=%d: малые значения должны храниться точно, бакет [%d, %d]", v, lo, hi)
+		}
+		if lo > 0 && float64(hi-lo)/float64(lo) > 0.001 {
+			t.Errorf("v=%d: ширина бакета [%d, %d] больше 0.1%%", v, lo, hi)
+		}
+	}
+}
+
+// randomLatencies — логнормальные задержки от микросекунд до секунд
+func randomLatencies(seed int64, n int) []time.Duration {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([]time.Duration, n)
+	for i := range out {
+		out[i] = time.Duration(math.Exp(rng.NormFloat64()*2+math.Log(5e6))) + time.Microsecond
+	}
+	return out
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	values := randomLatencies(1, 20000)
+	h := NewHistogram()
+	for _, v := range values {
+		h.Record(v)
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	if h.Count() != int64(len(values)) {
+		t.Fatalf("Count = %d, ожидается %d", h.Count(), len(values))
+	}
+	for _, p := range []float64{0, 1, 50, 90, 99, 99.9, 99.99, 100} {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		if rank < 1 {
+			rank = 1
+		}
+		want := sorted[rank-1]
+		got := h.Percentile(p)
+		// значения хранятся в микросекундах с точностью 0.1%
+		if diff := got - want; diff < -histUnit || diff > want/1000+histUnit {
+			t.Errorf("p%v = %s, ожидается %s", p, got, want)
+		}
+	}
+	if h.Min() != sorted[0] || h.Max() != sorted[len(sorted)-1] {
+		t.Errorf("min/max = %s/%s, ожидается %s/%s", h.Min(), h.Max(), sorted[0], sorted[len(sorted)-1])
+	}
+}
+
+func TestHistogramPercentileEmpty(t *testing.T) {
+	h := NewHistogram()
+	if got := h.Percentile(99); got != 0 {
+		t.Errorf("p99 пустой гистограммы = %s, ожидается 0", got)
+	}
+	h.Record(3 * time.Millisecond)
+	for _, p := range []float64{0, 50, 100} {
+		if got := h.Percentile(p); got != 3*time.Millisecond {
+			t.Errorf("p%v одного значения = %s, ожидается 3ms", p, got)
+		}
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	a, b := randomLatencies(2, 5000), randomLatencies(3, 3000)
+	all, ha, hb := NewHistogram(), NewHistogram(), NewHistogram()
+	for _, v := range a {
+		ha.Record(v)
+		all.Record(v)
+	}
+	for _, v := range b {
+		hb.Record(v)
+		all.Record(v)
+	}
+
+	tests := []struct {
+		name  string
+		parts []*Histogram
+		want  *Histogram
+	}{
+		{"две гистограммы", []*Histogram{ha, hb}, all},
+		{"в пустую", []*Histogram{hb}, hb},
+		{"пустая и nil", []*Histogram{ha, NewHistogram(), nil}, ha},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewHistogram()
+			for _, p := range tt.parts {
+				got.Merge(p)
+			}
+			assertSameHistogram(t, got, tt.want)
+		})
+	}
+}
+
+func TestHistogramJSONRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, 1000} {
+		h := NewHistogram()
+		for _, v := range randomLatencies(4, n) {
+			h.Record(v)
+		}
+		data, err := json.Marshal(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Histogram
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		assertSameHistogram(t, &got, h)
+	}
+}
+
+func TestHistogramJSONIncompatible(t *testing.T) {
+	var h Histogram
+	err := json.Unmarshal([]byte(`{"unit":"ms","significant_figures":3}`), &h)
+	if err == nil {
+		t.Fatal("гистограмма в других единицах должна отклоняться")
+	}
+}
+
+// assertSameHistogram сравнивает счётчики бакетов и сводные значения
+func assertSameHistogram(t *testing.T, got, want *Histogram) {
+	t.Helper()
+	if got.Count() != want.Count() || got.Min() != want.Min() || got.Max() != want.Max() {
+		t.Errorf("count/min/max = %d/%s/%s, ожидается %d/%s/%s",
+			got.Count(), got.Min(), got.Max(), want.Count(), want.Min(), want.Max())
+	}
+	if math.Abs(got.sum-want.sum) > 1e-6*want.sum || math.Abs(got.sumSq-want.sumSq) > 1e-6*want.sumSq {
+		t.Errorf("sum/sumSq = %g/%g, ожидается %g/%g", got.sum, got.sumSq, want.sum, want.sumSq)
+	}
+	n := max(len(got.counts), len(want.counts))
+	for i := 0; i < n; i++ {
+		var g, w int64
+		if i < len(got.counts) {
+			g = got.counts[i]
+		}
+		if i < len(want.counts) {
+			w = want.counts[i]
+		}
+		if g != w {
+			t.Errorf("бакет %d: %d, ожидается %d", i, g, w)
+			return
+		}
+	}
+}
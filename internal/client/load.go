@@ -0,0 +1,390 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/compression"
+)
+
+// LoadOptions — параметры нагрузки, общие для всех бенчмарков клиента
+type LoadOptions struct {
+	Requests          int                      // общее количество запросов
+	Concurrency       int                      // число параллельных воркеров (в open-loop — максимум запросов в полёте)
+	Scenario          LoadScenario             // сценарий нагрузки
+	TargetRPS         float64                  // целевая частота запросов (только для ScenarioOpenLoop)
+	Profile           Profile                  // форма нагрузки во времени; если задана, заменяет TargetRPS или Concurrency
+	ProfileTarget     ProfileTarget            // что задаёт Profile: RPS (open-loop) или число воркеров (closed-loop)
+	Duration          time.Duration            // длительность измеряемой фазы; если задана, Requests не используется
+	WarmUp            time.Duration            // разогрев перед измерением, его запросы не попадают в результаты
+	CoolDown          time.Duration            // нагрузка после измерения, её запросы не попадают в результаты
+	Timeout           time.Duration            // таймаут одного вызова; 0 — без таймаута
+	RPCTimeouts       map[string]time.Duration // таймауты отдельных RPC по короткому имени метода, вместо Timeout
+	Interval          time.Duration            // период снимков метрик во время прогона; 0 — без снимков
+	OnInterval        IntervalFunc             // получает снимки интервалов, если Interval > 0
+	Payload           Payload                  // содержимое отправляемых сообщений и размер ответов
+	Sweep             string                   // заполняется Sweep: какой размер меняет серия прогонов
+	Compression       string                   // алгоритм сжатия сообщений из Compressions; пусто — без сжатия
+	CompressionSeries bool                     // заполняется CompareCompression: прогон из сравнения алгоритмов сжатия
+	StreamWindow      int                      // StreamPing: 0 — новый поток на итерацию, N — долгоживущие потоки по N сообщений в полёте
+	Batch             Batch                    // AggregatePing: сообщений в потоке и пауза между ними
+	Push              PushStream               // PushNotifications: параметры потока, которые задаёт клиент
+}
+
+func (o LoadOptions) validate() error {
+	if o.Concurrency <= 0 {
+		return errors.New("concurrency должно быть больше нуля")
+	}
+	if o.Duration <= 0 && o.Requests <= 0 {
+		return errors.New("нужно задать количество запросов или длительность")
+	}
+	if o.StreamWindow < 0 {
+		return errors.New("окно потока не может быть отрицательным")
+	}
+	if o.Batch.Messages < 0 || o.Batch.Gap < 0 {
+		return errors.New("число сообщений в пачке и пауза между ними не могут быть отрицательными")
+	}
+	if err := o.Push.validate(); err != nil {
+		return err
+	}
+	if err := o.Payload.validate(); err != nil {
+		return err
+	}
+	if o.Compression != "" {
+		if err := compression.Check(o.Compression); err != nil {
+			return err
+		}
+	}
+	if o.Interval < 0 {
+		return errors.New("интервал снимков не может быть отрицательным")
+	}
+	if o.WarmUp < 0 || o.CoolDown < 0 {
+		return errors.New("длительность разогрева и остывания не может быть отрицательной")
+	}
+	if o.Profile != nil {
+		switch o.profileTarget() {
+		case ProfileRPS:
+			if o.Scenario != ScenarioOpenLoop {
+				return errors.New("RPS-профиль применяется только в open-loop сценарии")
+			}
+			// Профиль, заканчивающийся нулём, может так и не выдать все
+			// Requests запросов, и прогон без Duration не закончится
+			if n, ok := profileRequests(o.Profile, o.WarmUp); ok && o.Duration <= 0 && n < float64(o.Requests) {
+				return fmt.Errorf("RPS-профиль %v заканчивается нулевой нагрузкой и выдаст за измерение около %.0f запросов из %d: задайте длительность или профиль с ненулевым концом", o.Profile, n, o.Requests)
+			}
+		case ProfileConcurrency:
+			if o.Scenario == ScenarioOpenLoop {
+				return errors.New("профиль concurrency применяется только в closed-loop сценариях")
+			}
+		default:
+			return fmt.Errorf("неизвестный target профиля %q", o.ProfileTarget)
+		}
+		return nil
+	}
+	if o.Scenario == ScenarioOpenLoop && o.TargetRPS <= 0 {
+		return errors.New("для open-loop сценария нужен TargetRPS > 0 или RPS-профиль")
+	}
+	return nil
+}
+
+// MaxPayload — наибольший payload сообщений нагрузки в обе стороны
+func (o LoadOptions) MaxPayload() int {
+	return max(o.Payload.Size.Max(), o.Payload.ResponseSize.Max(), o.Push.PayloadSize)
+}
+
+// timeout — таймаут вызова нагрузки w: из RPCTimeouts по короткому имени
+// метода или имени нагрузки, иначе Timeout
+func (o LoadOptions) timeout(w Workload) time.Duration {
+	if d, ok := o.RPCTimeouts[methodName(w.Method())]; ok {
+		return d
+	}
+	if d, ok := o.RPCTimeouts[w.Name()]; ok {
+		return d
+	}
+	return o.Timeout
+}
+
+// callContext — контекст одного вызова с таймаутом timeout; 0 — без таймаута
+func callContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// ParseRPCTimeouts разбирает таймауты RPC вида "Ping=100ms,StreamPing=1s".
+// Имена сервиса бенчмарка (и их синонимы) приводятся к каноническим,
+// остальные — короткие имена методов как есть.
+func ParseRPCTimeouts(spec string) (map[string]time.Duration, error) {
+	specs := map[string]string{}
+	for _, kv := range strings.Split(spec, ",") {
+		name, v, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("таймауты %q: ожидается RPC=длительность, получено %q", spec, kv)
+		}
+		specs[name] = v
+	}
+	return newRPCTimeouts(specs)
+}
+
+// newRPCTimeouts разбирает длительности таймаутов RPC и приводит имена
+func newRPCTimeouts(specs map[string]string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(specs))
+	for name, v := range specs {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("таймаут %s: %v", name, err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("таймаут %s не может быть отрицательным", name)
+		}
+		if rpc, err := RPCName(name); err == nil {
+			name = rpc
+		}
+		timeouts[name] = d
+	}
+	return timeouts, nil
+}
+
+// methodName — короткое имя gRPC метода: "Ping" для "/benchmark.BenchmarkService/Ping"
+func methodName(method string) string {
+	return method[strings.LastIndex(method, "/")+1:]
+}
+
+func (o LoadOptions) profileTarget() ProfileTarget {
+	if o.ProfileTarget == "" {
+		return ProfileRPS
+	}
+	return o.ProfileTarget
+}
+
+// rateProfile — профиль частоты для open-loop: заданный или постоянный TargetRPS
+func (o LoadOptions) rateProfile() Profile {
+	if o.Profile != nil {
+		return o.Profile
+	}
+	return ConstantProfile{Value: o.TargetRPS}
+}
+
+// loadRun — итог работы runLoad
+type loadRun struct {
+	elapsed     time.Duration // общее время выполнения, включая разогрев и остывание
+	measured    time.Duration // длительность измеряемой фазы
+	intendedRPS float64       // средняя частота измеряемой фазы по расписанию (только open-loop)
+	requests    int64         // измеряемых запросов
+	discarded   int64         // запросов в фазах разогрева и остывания
+}
+
+// request — один вызов, выданный воркеру
+type request struct {
+	worker   int
+	seq      int           // сквозной номер вызова в прогоне
+	intended time.Time     // запланированное время отправки
+	measured bool          // false для запросов разогрева и остывания
+	excluded time.Duration // вычитается из задержки, см. ExcludeFromLatency
+}
+
+// profileIdleStep — шаг, с которым проверяется профиль, пока он требует нулевую нагрузку
+const profileIdleStep = 10 * time.Millisecond
+
+// runLoad запускает воркеры и вызывает fn для каждого запроса.
+// r.intended — момент, когда запрос должен был уйти по расписанию. В closed-loop
+// режиме это фактическое время отправки, в open-loop — время из расписания,
+// поэтому latency, посчитанная от intended, учитывает coordinated omission.
+//
+// Прогон состоит из фаз: разогрев (WarmUp), измерение (Duration или Requests
+// запросов) и остывание (CoolDown). Нагрузка подаётся во всех фазах,
+// но в результаты попадают только запросы с r.measured.
+func runLoad(opts LoadOptions, fn func(r request)) loadRun {
+	var wg sync.WaitGroup
+	wg.Add(opts.Concurrency)
+	start := time.Now()
+	ph := &phaseClock{opts: opts, start: start}
+	var run loadRun
+	var lastMeasured int64 // UnixNano завершения последнего измеряемого запроса
+
+	call := func(r request) {
+		fn(r)
+		if !r.measured {
+			atomic.AddInt64(&run.discarded, 1)
+			return
+		}
+		atomic.AddInt64(&run.requests, 1)
+		done := time.Now().UnixNano()
+		for {
+			prev := atomic.LoadInt64(&lastMeasured)
+			if done <= prev || atomic.CompareAndSwapInt64(&lastMeasured, prev, done) {
+				break
+			}
+		}
+	}
+
+	if opts.Scenario == ScenarioOpenLoop {
+		tickets := scheduleOpenLoop(ph, opts.rateProfile(), opts.Concurrency, &run.intendedRPS)
+		for w := 0; w < opts.Concurrency; w++ {
+			go func(workerID int) {
+				defer wg.Done()
+				for r := range tickets {
+					r.worker = workerID
+					call(r)
+				}
+			}(w)
+		}
+	} else {
+		active := opts.Profile
+		for w := 0; w < opts.Concurrency; w++ {
+			go func(workerID int) {
+				defer wg.Done()
+				for {
+					if active != nil {
+						waitActive(start, workerID, active, ph.over)
+					}
+					r, ok := ph.next(time.Now())
+					if !ok {
+						return
+					}
+					r.worker = workerID
+					call(r)
+					scenarioPause(opts.Scenario)
+				}
+			}(w)
+		}
+	}
+
+	wg.Wait()
+	run.elapsed = time.Since(start)
+	if last := atomic.LoadInt64(&lastMeasured); last > 0 {
+		run.measured = time.Unix(0, last).Sub(start.Add(opts.WarmUp))
+	}
+	return run
+}
+
+// phaseClock определяет фазу прогона для очередного запроса.
+// В режиме Duration фазы заданы временем. В режиме Requests измерение
+// заканчивается, когда выдан последний измеряемый запрос, и с этого
+// момента отсчитывается остывание.
+type phaseClock struct {
+	opts       LoadOptions
+	start      time.Time
+	calls      int64 // выдано запросов всего
+	measured   int64 // выдано измеряемых запросов
+	measureEnd int64 // UnixNano конца измерения в режиме Requests, 0 — ещё не наступил
+}
+
+// next выдаёт запрос, отправляемый в момент at; ok=false — прогон окончен
+func (p *phaseClock) next(at time.Time) (r request, ok bool) {
+	r.intended = at
+	elapsed := at.Sub(p.start)
+	switch {
+	case elapsed < p.opts.WarmUp:
+	case p.opts.Duration > 0:
+		if elapsed >= p.opts.WarmUp+p.opts.Duration+p.opts.CoolDown {
+			return r, false
+		}
+		r.measured = elapsed < p.opts.WarmUp+p.opts.Duration
+	default:
+		if atomic.AddInt64(&p.measured, 1) <= int64(p.opts.Requests) {
+			r.measured = true
+			break
+		}
+		atomic.CompareAndSwapInt64(&p.measureEnd, 0, at.UnixNano())
+		if at.UnixNano() >= atomic.LoadInt64(&p.measureEnd)+int64(p.opts.CoolDown) {
+			return r, false
+		}
+	}
+	r.seq = int(atomic.AddInt64(&p.calls, 1)) - 1
+	return r, true
+}
+
+// over сообщает, что прогон окончен и новые запросы выдаваться не будут
+func (p *phaseClock) over() bool {
+	elapsed := time.Since(p.start)
+	if p.opts.Duration > 0 {
+		return elapsed >= p.opts.WarmUp+p.opts.Duration+p.opts.CoolDown
+	}
+	end := atomic.LoadInt64(&p.measureEnd)
+	return end > 0 && time.Now().UnixNano() >= end+int64(p.opts.CoolDown)
+}
+
+// waitActive блокирует воркер, пока профиль concurrency не включит его
+// или пока done не сообщит, что прогон окончен. Воркер 0 активен всегда,
+// чтобы прогон с фиксированным числом запросов не зависал на участках
+// профиля с нулевой нагрузкой.
+func waitActive(start time.Time, workerID int, profile Profile, done func() bool) {
+	for workerID > 0 && float64(workerID) >= math.Round(profile.At(time.Since(start))) && !done() {
+		time.Sleep(profileIdleStep)
+	}
+}
+
+// scheduleOpenLoop выдаёт запросы строго по расписанию с частотой из профиля,
+// не дожидаясь ответов. Если все воркеры заняты, запросы копятся в очереди,
+// а их intended-время остаётся прежним. После закрытия канала в intendedRPS
+// записана средняя частота измеряемой фазы по расписанию.
+func scheduleOpenLoop(ph *phaseClock, rate Profile, buffer int, intendedRPS *float64) <-chan request {
+	tickets := make(chan request, buffer)
+	go func() {
+		defer close(tickets)
+		var at, firstMeasured, afterMeasured time.Duration
+		var measured int
+		idleEnd, endsIdle := profileEnd(rate)
+		for {
+			rps := rate.At(at)
+			if rps <= 0 {
+				// Профиль закончился нулевой нагрузкой — запросов больше не будет
+				if ph.opts.Duration <= 0 && endsIdle && at >= idleEnd {
+					break
+				}
+				at += profileIdleStep
+				if ph.opts.Duration > 0 && at >= ph.opts.WarmUp+ph.opts.Duration+ph.opts.CoolDown {
+					break
+				}
+				// Расписание не убегает вперёд реального времени, пока нагрузки нет
+				if d := time.Until(ph.start.Add(at)); d > 0 {
+					time.Sleep(d)
+				}
+				continue
+			}
+			intended := ph.start.Add(at)
+			r, ok := ph.next(intended)
+			if !ok {
+				break
+			}
+			if d := time.Until(intended); d > 0 {
+				time.Sleep(d)
+			}
+			tickets <- r
+			// При rps больше 1e9 шаг округляется до нуля, расписание не должно стоять
+			at += max(time.Duration(float64(time.Second)/rps), time.Nanosecond)
+			if r.measured {
+				if measured == 0 {
+					firstMeasured = intended.Sub(ph.start)
+				}
+				measured++
+				afterMeasured = at
+			}
+		}
+		if span := afterMeasured - firstMeasured; span > 0 {
+			*intendedRPS = float64(measured) / span.Seconds()
+		}
+	}()
+	return tickets
+}
+
+// scenarioPause — пауза между запросами для closed-loop сценариев
+func scenarioPause(scenario LoadScenario) {
+	switch scenario {
+	case ScenarioLight:
+		time.Sleep(100 * time.Millisecond)
+	case ScenarioPeak:
+		time.Sleep(time.Duration(rand.Intn(10)) * time.Millisecond)
+	case ScenarioConstant:
+		// без пауз
+	}
+}
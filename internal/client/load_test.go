@@ -0,0 +1,318 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/status"
+)
+
+func TestValidateProfileEndingAtZero(t *testing.T) {
+	profile, _, err := ParseProfile("piecewise:0s=100,1s=100,2s=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		opts    LoadOptions
+		wantErr bool
+	}{
+		{"запросов больше, чем выдаст профиль", LoadOptions{Requests: 500}, true},
+		{"профиль успевает выдать запросы", LoadOptions{Requests: 100}, false},
+		{"разогрев съедает запросы", LoadOptions{Requests: 100, WarmUp: time.Second}, true},
+		{"прогон по длительности", LoadOptions{Duration: 5 * time.Second}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Concurrency, opts.Scenario, opts.Profile = 1, ScenarioOpenLoop, profile
+			err := opts.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() = %v, ожидается ошибка: %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "нулевой нагрузкой") {
+				t.Errorf("непонятная ошибка: %v", err)
+			}
+		})
+	}
+}
+
+// Расписание по профилю, закончившемуся нулём, должно закрываться, даже
+// если профиль не успел выдать все запросы
+func TestScheduleOpenLoopProfileEnds(t *testing.T) {
+	opts := LoadOptions{Requests: 1000, Concurrency: 1, Scenario: ScenarioOpenLoop}
+	ph := &phaseClock{opts: opts, start: time.Now()}
+	profile := PiecewiseProfile{Points: []ProfilePoint{{0, 1000}, {20 * time.Millisecond, 1000}, {30 * time.Millisecond, 0}}}
+	var intended float64
+	tickets := scheduleOpenLoop(ph, profile, 1000, &intended)
+
+	n := 0
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-tickets:
+			if !ok {
+				if n == 0 || n >= opts.Requests {
+					t.Errorf("выдано %d запросов", n)
+				}
+				return
+			}
+			n++
+		case <-timeout:
+			t.Fatalf("расписание не закрылось, выдано %d запросов", n)
+		}
+	}
+}
+
+// При частоте больше 1e9 шаг расписания не должен округляться до нуля
+func TestScheduleOpenLoopHugeRate(t *testing.T) {
+	opts := LoadOptions{Requests: 10, Concurrency: 1, Scenario: ScenarioOpenLoop}
+	ph := &phaseClock{opts: opts, start: time.Now()}
+	var intended float64
+	var last time.Time
+	for r := range scheduleOpenLoop(ph, ConstantProfile{Value: 1e12}, 100, &intended) {
+		if !r.intended.After(last) {
+			t.Fatalf("intended %v не позже предыдущего %v", r.intended, last)
+		}
+		last = r.intended
+	}
+}
+
+// Запросы open-loop идут строго через 1/rps от старта, а частота по
+// расписанию считается только по измеряемой фазе
+func TestScheduleOpenLoopRate(t *testing.T) {
+	opts := LoadOptions{Requests: 20, Concurrency: 1, Scenario: ScenarioOpenLoop}
+	start := time.Now()
+	ph := &phaseClock{opts: opts, start: start}
+	var intended float64
+	i := 0
+	for r := range scheduleOpenLoop(ph, ConstantProfile{Value: 1000}, 1, &intended) {
+		if want := start.Add(time.Duration(i) * time.Millisecond); !r.intended.Equal(want) || r.seq != i || !r.measured {
+			t.Fatalf("запрос %d: intended +%s, seq %d, measured %v", i, r.intended.Sub(start), r.seq, r.measured)
+		}
+		i++
+	}
+	if i != opts.Requests || math.Abs(intended-1000) > 1e-6 {
+		t.Errorf("выдано %d запросов с частотой %g, ожидается %d и 1000", i, intended, opts.Requests)
+	}
+}
+
+// Open-loop считает задержку от времени по расписанию: если сервер не
+// успевает, задержка растёт с очередью (коррекция coordinated omission),
+// а closed-loop видит только время самого вызова
+func TestOpenLoopCoordinatedOmission(t *testing.T) {
+	const call = 10 * time.Millisecond
+	w := FuncWorkload{Label: "slow", Fn: func(context.Context, Iteration) error {
+		time.Sleep(call)
+		return nil
+	}}
+	open, err := RunWorkload(w, LoadOptions{Scenario: ScenarioOpenLoop, TargetRPS: 500, Concurrency: 1, Requests: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// последний запрос ждёт 19 вызовов по 10ms при шаге расписания 2ms
+	if max := open.Histogram.Max(); max < 19*(call-2*time.Millisecond) {
+		t.Errorf("open-loop: наибольшая задержка %s не учитывает очередь", max)
+	}
+	if math.Abs(open.IntendedRPS-500) > 1e-6 || open.RPS > 110 {
+		t.Errorf("open-loop: по расписанию %g RPS, фактически %g; ожидается 500 и не больше 100", open.IntendedRPS, open.RPS)
+	}
+
+	closed, err := RunWorkload(w, LoadOptions{Scenario: ScenarioConstant, Concurrency: 1, Requests: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max := closed.Histogram.Max(); max > 5*call {
+		t.Errorf("closed-loop: наибольшая задержка %s", max)
+	}
+}
+
+func TestValidateOpenLoop(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    LoadOptions
+		wantErr string
+	}{
+		{"без частоты", LoadOptions{Scenario: ScenarioOpenLoop, Concurrency: 1, Requests: 1}, "TargetRPS"},
+		{"с частотой", LoadOptions{Scenario: ScenarioOpenLoop, Concurrency: 1, Requests: 1, TargetRPS: 10}, ""},
+		{"без воркеров", LoadOptions{Scenario: ScenarioOpenLoop, Requests: 1, TargetRPS: 10}, "concurrency"},
+		{"RPS-профиль в closed-loop", LoadOptions{Scenario: ScenarioConstant, Concurrency: 1, Requests: 1, Profile: ConstantProfile{Value: 10}}, "open-loop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ошибка %v, ожидается %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPhaseClockDuration(t *testing.T) {
+	opts := LoadOptions{WarmUp: time.Second, Duration: 2 * time.Second, CoolDown: time.Second}
+	start := time.Now()
+	ph := &phaseClock{opts: opts, start: start}
+	tests := []struct {
+		at           time.Duration
+		ok, measured bool
+	}{
+		{0, true, false},
+		{999 * time.Millisecond, true, false},
+		{time.Second, true, true},
+		{2999 * time.Millisecond, true, true},
+		{3 * time.Second, true, false},
+		{3999 * time.Millisecond, true, false},
+		{4 * time.Second, false, false},
+	}
+	for i, tt := range tests {
+		r, ok := ph.next(start.Add(tt.at))
+		if ok != tt.ok || r.measured != tt.measured {
+			t.Errorf("+%s: ok %v, measured %v; ожидается %v, %v", tt.at, ok, r.measured, tt.ok, tt.measured)
+		}
+		if ok && r.seq != i {
+			t.Errorf("+%s: seq %d, ожидается %d", tt.at, r.seq, i)
+		}
+	}
+}
+
+// В режиме Requests измерение кончается на последнем измеряемом запросе,
+// и от него отсчитывается остывание
+func TestPhaseClockRequests(t *testing.T) {
+	opts := LoadOptions{WarmUp: time.Second, Requests: 2, CoolDown: time.Second}
+	// старт в прошлом, чтобы over() по текущему времени видел конец остывания
+	start := time.Now().Add(-10 * time.Second)
+	ph := &phaseClock{opts: opts, start: start}
+	tests := []struct {
+		at           time.Duration
+		ok, measured bool
+	}{
+		{500 * time.Millisecond, true, false}, // разогрев
+		{5 * time.Second, true, true},
+		{6 * time.Second, true, true},
+		{7 * time.Second, true, false}, // остывание от 7s
+		{7999 * time.Millisecond, true, false},
+		{8 * time.Second, false, false},
+	}
+	for _, tt := range tests {
+		r, ok := ph.next(start.Add(tt.at))
+		if ok != tt.ok || r.measured != tt.measured {
+			t.Errorf("+%s: ok %v, measured %v; ожидается %v, %v", tt.at, ok, r.measured, tt.ok, tt.measured)
+		}
+	}
+	if !ph.over() {
+		t.Error("over() = false после конца остывания")
+	}
+}
+
+// Результат прогона по времени: измеряемая фаза, запросы разогрева и
+// остывания в discarded, длительность всего прогона в elapsed
+func TestRunWorkloadPhases(t *testing.T) {
+	w := FuncWorkload{Label: "f", Fn: func(context.Context, Iteration) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}}
+	opts := LoadOptions{Scenario: ScenarioConstant, Concurrency: 2, WarmUp: 50 * time.Millisecond,
+		Duration: 100 * time.Millisecond, CoolDown: 50 * time.Millisecond}
+	res, err := RunWorkload(w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Requests == 0 || res.Discarded == 0 || res.Success != res.Requests || res.Histogram.Count() != res.Requests {
+		t.Errorf("запросов %d, успешных %d, в гистограмме %d, отброшено %d", res.Requests, res.Success, res.Histogram.Count(), res.Discarded)
+	}
+	if res.Measured < 90*time.Millisecond || res.Measured > 150*time.Millisecond || res.Elapsed < 200*time.Millisecond {
+		t.Errorf("измерение %s, весь прогон %s", res.Measured, res.Elapsed)
+	}
+	if want := float64(res.Requests) / res.Measured.Seconds(); math.Abs(res.RPS-want) > 1e-6 {
+		t.Errorf("RPS %g, ожидается запросы/измерение = %g", res.RPS, want)
+	}
+}
+
+func TestParseRPCTimeouts(t *testing.T) {
+	tests := []struct {
+		spec string
+		want map[string]time.Duration // nil — ошибка
+	}{
+		{"Ping=100ms,StreamPing=1s", map[string]time.Duration{"Ping": 100 * time.Millisecond, "StreamPing": time.Second}},
+		{"unary=50ms, push=0s", map[string]time.Duration{"Ping": 50 * time.Millisecond, "PushNotifications": 0}},
+		{"SayHello=2s", map[string]time.Duration{"SayHello": 2 * time.Second}},
+		{"Ping", nil},
+		{"=1s", nil},
+		{"Ping=fast", nil},
+		{"Ping=-1s", nil},
+	}
+	for _, tt := range tests {
+		got, err := ParseRPCTimeouts(tt.spec)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("ParseRPCTimeouts(%q) = %v, ожидается ошибка", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ParseRPCTimeouts(%q) = %v, %v; ожидается %v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+// Таймаут нагрузки ищется по короткому имени метода, затем по имени
+// нагрузки; без них действует общий Timeout
+func TestLoadOptionsTimeout(t *testing.T) {
+	w := FuncWorkload{Label: "UnaryPing", FullMethod: "/benchmark.BenchmarkService/Ping"}
+	tests := []struct {
+		name     string
+		timeouts map[string]time.Duration
+		want     time.Duration
+	}{
+		{"общий таймаут", nil, time.Second},
+		{"по методу", map[string]time.Duration{"Ping": 10 * time.Millisecond, "UnaryPing": 20 * time.Millisecond}, 10 * time.Millisecond},
+		{"по имени нагрузки", map[string]time.Duration{"UnaryPing": 20 * time.Millisecond}, 20 * time.Millisecond},
+		{"без таймаута для RPC", map[string]time.Duration{"Ping": 0}, 0},
+		{"другой RPC", map[string]time.Duration{"StreamPing": 10 * time.Millisecond}, time.Second},
+	}
+	for _, tt := range tests {
+		opts := LoadOptions{Timeout: time.Second, RPCTimeouts: tt.timeouts}
+		if got := opts.timeout(w); got != tt.want {
+			t.Errorf("%s: таймаут %v, ожидается %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Вызовы, не уложившиеся в таймаут, считаются ошибками и отдельно
+// в DeadlineExceeded; таймаут попадает в параметры результата
+func TestRunWorkloadDeadlineExceeded(t *testing.T) {
+	w := FuncWorkload{Label: "f", FullMethod: "/svc/F", Fn: func(ctx context.Context, it Iteration) error {
+		if it.Seq%4 != 0 {
+			return nil
+		}
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	}}
+	opts := LoadOptions{
+		Scenario:    ScenarioConstant,
+		Concurrency: 2,
+		Requests:    40,
+		Timeout:     time.Second,
+		RPCTimeouts: map[string]time.Duration{"F": 5 * time.Millisecond},
+	}
+	res, err := RunWorkload(w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Failures != 10 || res.DeadlineExceeded != 10 || res.Success != 30 {
+		t.Errorf("успешных %d, ошибок %d, дедлайн превышен у %d", res.Success, res.Failures, res.DeadlineExceeded)
+	}
+	if res.Params.Timeout != 5*time.Millisecond {
+		t.Errorf("таймаут в результате %v", res.Params.Timeout)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"sync"
 )
 
@@ -25,7 +26,7 @@ func InitLogger(filePath string) error {
 	var err error
 	initOnce.Do(func() {
 		// Создаём папку, если её нет
-		if err = os.MkdirAll("../../logs", 0755); err != nil {
+		if err = os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 			return
 		}
 
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/stats"
+)
+
+// Метрики вызовов со стороны клиента. Имена повторяют метрики сервера
+// (internal/server/metrics.go) с префиксом grpc_client_, лейблы и бакеты
+// те же, поэтому задержку клиента и сервера можно сравнивать одним
+// запросом PromQL по лейблу method.
+var (
+	ClientRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_client_rpc_requests_total",
+			Help: "Total number of gRPC calls made by the benchmark client",
+		},
+		[]string{"method", "status"},
+	)
+	ClientLatencySeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_client_rpc_latency_seconds",
+			Help:    "Latency distribution of gRPC calls observed by the benchmark client",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+	ClientRequestSizeBytes  = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "grpc_client_rpc_request_size_bytes", Help: "Size of gRPC request messages sent by the benchmark client"}, []string{"method"})
+	ClientResponseSizeBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "grpc_client_rpc_response_size_bytes", Help: "Size of gRPC response messages received by the benchmark client"}, []string{"method"})
+)
+
+func init() {
+	prometheus.MustRegister(ClientRequestsTotal, ClientLatencySeconds, ClientRequestSizeBytes, ClientResponseSizeBytes)
+}
+
+// MetricsHandler — обработчик статистики gRPC, который пишет метрики
+// Prometheus по каждому вызову соединения. Подключается через
+// grpc.WithStatsHandler рядом с StatsHandler. Вызов потока учитывается
+// один раз — от открытия до завершения, как на сервере; размеры —
+// по каждому сообщению до сжатия.
+func MetricsHandler() stats.Handler { return metricsHandler{} }
+
+type metricsHandler struct{}
+
+type metricsKey struct{}
+
+func (metricsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, metricsKey{}, info.FullMethodName)
+}
+
+func (metricsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	method, ok := ctx.Value(metricsKey{}).(string)
+	if !ok {
+		return
+	}
+	switch p := s.(type) {
+	case *stats.OutPayload:
+		ClientRequestSizeBytes.WithLabelValues(method).Observe(float64(p.Length))
+	case *stats.InPayload:
+		ClientResponseSizeBytes.WithLabelValues(method).Observe(float64(p.Length))
+	case *stats.End:
+		status := "success"
+		if p.Error != nil {
+			status = "error"
+		}
+		ClientRequestsTotal.WithLabelValues(method, status).Inc()
+		ClientLatencySeconds.WithLabelValues(method).Observe(p.EndTime.Sub(p.BeginTime).Seconds())
+	}
+}
+
+func (metricsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (metricsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+// StartMetricsEndpoint начинает отдавать метрики клиента на addr/metrics.
+// В отличие от сервера порт занимается сразу, чтобы ошибка адреса
+// остановила клиент до начала прогона.
+func StartMetricsEndpoint(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("metrics endpoint: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(lis); err != nil && err != http.ErrServerClosed {
+			LogInfo("Ошибка metrics endpoint: %v", err)
+		}
+	}()
+	LogInfo("Метрики Prometheus доступны на %s/metrics", lis.Addr())
+	return nil
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// MixName — имя сводного результата смешанной нагрузки
+const MixName = "Mix"
+
+// MixEntry — доля одного RPC в смешанной нагрузке
+type MixEntry struct {
+	RPC    string
+	Weight float64
+}
+
+// ParseMix разбирает смесь вида "Ping=80,StreamPing=15,AggregatePing=5".
+// Веса относительные и не обязаны давать в сумме 100.
+func ParseMix(spec string) ([]MixEntry, error) {
+	weights := map[string]float64{}
+	for _, kv := range strings.Split(spec, ",") {
+		name, w, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok {
+			return nil, fmt.Errorf("смесь %q: ожидается RPC=вес, получено %q", spec, kv)
+		}
+		weight, err := strconv.ParseFloat(w, 64)
+		if err != nil {
+			return nil, fmt.Errorf("смесь %q: вес %s: %v", spec, name, err)
+		}
+		weights[name] = weight
+	}
+	return newMix(weights)
+}
+
+// newMix проверяет веса и упорядочивает RPC как в RPCs
+func newMix(weights map[string]float64) ([]MixEntry, error) {
+	byRPC := map[string]float64{}
+	for name, w := range weights {
+		rpc, err := RPCName(name)
+		if err != nil {
+			return nil, err
+		}
+		if w < 0 {
+			return nil, fmt.Errorf("отрицательный вес %s", name)
+		}
+		byRPC[rpc] += w
+	}
+	var mix []MixEntry
+	for _, rpc := range RPCs {
+		if w := byRPC[rpc]; w > 0 {
+			mix = append(mix, MixEntry{RPC: rpc, Weight: w})
+		}
+	}
+	if len(mix) == 0 {
+		return nil, errors.New("в смеси нет RPC с положительным весом")
+	}
+	return mix, nil
+}
+
+// FormatMix возвращает смесь в виде, из которого она разбирается
+func FormatMix(mix []MixEntry) string {
+	parts := make([]string, len(mix))
+	for i, m := range mix {
+		parts[i] = m.RPC + "=" + strconv.FormatFloat(m.Weight, 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+// WeightedWorkload — нагрузка и её вес в смеси
+type WeightedWorkload struct {
+	Workload Workload
+	Weight   float64
+}
+
+// Mix подаёт смешанную нагрузку из RPC сервиса, см. RunMix.
+// PushNotifications в смеси — один поток, прочитанный до конца.
+func Mix(client pb.BenchmarkServiceClient, mix []MixEntry, opts LoadOptions) ([]*Result, error) {
+	spec := FormatMix(mix)
+	log.Printf("=== Смешанная нагрузка: %s ===", spec)
+	client = withCompression(client, opts.Compression)
+	workloads := make([]WeightedWorkload, len(mix))
+	for i, m := range mix {
+		w, err := RPCWorkload(client, m.RPC, opts)
+		if err != nil {
+			return nil, err
+		}
+		workloads[i] = WeightedWorkload{Workload: w, Weight: m.Weight}
+	}
+	results, err := RunMix(workloads, opts)
+	for _, res := range results {
+		res.Params.Mix = spec
+	}
+	return results, err
+}
+
+// RunMix подаёт смешанную нагрузку: каждая итерация воркера уходит
+// в нагрузку, выбранную случайно пропорционально весам. Возвращает
+// результаты по каждой нагрузке и сводный результат MixName по всем
+// итерациям.
+func RunMix(mix []WeightedWorkload, opts LoadOptions) ([]*Result, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if len(mix) == 0 {
+		return nil, errors.New("пустая смесь нагрузок")
+	}
+
+	stats := make([]*workerStatsSet, len(mix))
+	cumulative := make([]float64, len(mix))
+	var total float64
+	for i, m := range mix {
+		if m.Weight <= 0 {
+			return nil, fmt.Errorf("%s: вес должен быть больше нуля", m.Workload.Name())
+		}
+		total += m.Weight
+		cumulative[i] = total
+	}
+	for i, m := range mix {
+		if err := m.Workload.Setup(context.Background()); err != nil {
+			teardown(mix[:i])
+			return nil, fmt.Errorf("%s: подготовка: %v", m.Workload.Name(), err)
+		}
+		stats[i] = newWorkerStats(m.Workload.Name(), opts)
+	}
+	all := newWorkerStats(MixName, opts)
+
+	// Свой генератор у каждого воркера, чтобы выбор нагрузки не блокировался
+	rngs := make([]*rand.Rand, opts.Concurrency)
+	seed := time.Now().UnixNano()
+	for i := range rngs {
+		rngs[i] = rand.New(rand.NewSource(seed + int64(i)))
+	}
+
+	methods := []string{""}
+	for _, m := range mix {
+		methods = append(methods, m.Workload.Method())
+	}
+	usage := startUsage(methods...)
+	started := time.Now()
+	run := runLoad(opts, func(r request) {
+		x := rngs[r.worker].Float64() * total
+		i := 0
+		for i < len(cumulative)-1 && x >= cumulative[i] {
+			i++
+		}
+		sc, err := execute(mix[i].Workload, opts, &r)
+		stats[i].of(r).record(r, err, sc)
+		all.of(r).record(r, err, sc)
+	})
+
+	cpu := usage.cpuTime()
+	results := make([]*Result, 0, len(mix)+1)
+	for i, m := range mix {
+		// Счётчики прогона у каждой нагрузки свои, длительность фаз общая
+		wRun := run
+		wRun.requests, wRun.discarded = stats[i].counts()
+		wRun.intendedRPS = run.intendedRPS * m.Weight / total
+		res := newResult(m.Workload.Name(), m.Workload.Method(), opts, started, wRun, stats[i])
+		res.Params.Timeout = opts.timeout(m.Workload)
+		res.Traffic = usage.trafficOf(m.Workload.Method())
+		finishResult(m.Workload, res)
+		results = append(results, res)
+	}
+	// Процессорное время не делится между RPC смеси, оно есть только у сводного результата
+	summary := newResult(MixName, "", opts, started, run, all)
+	summary.CPUTime, summary.Traffic = cpu, usage.trafficOf("")
+	results = append(results, summary)
+	return results, teardown(mix)
+}
+
+// teardown завершает нагрузки и возвращает первую ошибку
+func teardown(mix []WeightedWorkload) error {
+	var first error
+	for _, m := range mix {
+		if err := m.Workload.Teardown(context.Background()); err != nil && first == nil {
+			first = fmt.Errorf("%s: завершение: %v", m.Workload.Name(), err)
+		}
+	}
+	return first
+}
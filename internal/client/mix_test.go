@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string // FormatMix; пусто — ошибка
+	}{
+		{"Ping=80,StreamPing=15,AggregatePing=5", "Ping=80,StreamPing=15,AggregatePing=5"},
+		{"aggregate=1, unary=3", "Ping=3,AggregatePing=1"},
+		{"Ping=1,unary=2", "Ping=3"},
+		{"Ping=1,push=0", "Ping=1"},
+		{"Ping=0.5,StreamPing=0.25", "Ping=0.5,StreamPing=0.25"},
+		{"Ping", ""},
+		{"Ping=x", ""},
+		{"Ping=-1,StreamPing=2", ""},
+		{"Ping=0", ""},
+		{"Stats=1", ""},
+	}
+	for _, tt := range tests {
+		mix, err := ParseMix(tt.spec)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseMix(%q) = %s, ожидается ошибка", tt.spec, FormatMix(mix))
+			}
+			continue
+		}
+		if err != nil || FormatMix(mix) != tt.want {
+			t.Errorf("ParseMix(%q) = %s, %v; ожидается %s", tt.spec, FormatMix(mix), err, tt.want)
+		}
+	}
+}
+
+// Итерации распределяются между нагрузками пропорционально весам,
+// сводный результат содержит все итерации
+func TestRunMixWeights(t *testing.T) {
+	const requests = 20000
+	weights := []float64{6, 3, 1}
+	counts := make([]atomic.Int64, len(weights))
+	mix := make([]WeightedWorkload, len(weights))
+	for i, w := range weights {
+		mix[i] = WeightedWorkload{Weight: w, Workload: FuncWorkload{
+			Label: string(rune('a' + i)),
+			Fn: func(context.Context, Iteration) error {
+				counts[i].Add(1)
+				return nil
+			},
+		}}
+	}
+	results, err := RunMix(mix, LoadOptions{Scenario: ScenarioConstant, Concurrency: 4, Requests: requests})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(mix)+1 || results[len(mix)].Name != MixName {
+		t.Fatalf("результатов %d, ожидается %d со сводным последним", len(results), len(mix)+1)
+	}
+	if all := results[len(mix)]; all.Requests != requests {
+		t.Errorf("в сводном результате %d запросов, ожидается %d", all.Requests, requests)
+	}
+	for i, w := range weights {
+		share := float64(counts[i].Load()) / requests
+		if want := w / 10; math.Abs(share-want) > 0.02 {
+			t.Errorf("%s: доля %.3f, ожидается %.1f", mix[i].Workload.Name(), share, want)
+		}
+		if results[i].Requests != counts[i].Load() {
+			t.Errorf("%s: в результате %d запросов, выполнено %d", results[i].Name, results[i].Requests, counts[i].Load())
+		}
+	}
+}
+
+func TestRunMixErrors(t *testing.T) {
+	ok := FuncWorkload{Label: "ok", Fn: func(context.Context, Iteration) error { return nil }}
+	opts := LoadOptions{Scenario: ScenarioConstant, Concurrency: 1, Requests: 1}
+	tests := []struct {
+		name string
+		mix  []WeightedWorkload
+		err  string
+	}{
+		{"пустая смесь", nil, "пустая смесь"},
+		{"нулевой вес", []WeightedWorkload{{Workload: ok, Weight: 1}, {Workload: ok, Weight: 0}}, "вес должен быть больше нуля"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := RunMix(tt.mix, opts)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ошибка %v, ожидается с %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// Ошибки нагрузки учитываются и в её результате, и в сводном
+func TestRunMixFailures(t *testing.T) {
+	fail := FuncWorkload{Label: "fail", Fn: func(context.Context, Iteration) error { return errors.New("fail") }}
+	ok := FuncWorkload{Label: "ok", Fn: func(context.Context, Iteration) error { return nil }}
+	results, err := RunMix([]WeightedWorkload{{Workload: ok, Weight: 1}, {Workload: fail, Weight: 1}},
+		LoadOptions{Scenario: ScenarioConstant, Concurrency: 2, Requests: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	okRes, failRes, all := results[0], results[1], results[2]
+	if okRes.Failures != 0 || failRes.Success != 0 || failRes.Failures == 0 {
+		t.Errorf("ok: %d ошибок, fail: %d успешных, %d ошибок", okRes.Failures, failRes.Success, failRes.Failures)
+	}
+	if all.Success != okRes.Success || all.Failures != failRes.Failures {
+		t.Errorf("сводный результат: %d успешных, %d ошибок", all.Success, all.Failures)
+	}
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Семейства метрик итогового файла OpenMetrics. Гистограмма задержки
+// использует бакеты grpc_rpc_latency_seconds сервера.
+const (
+	omRequests    = "grpc_bench_requests"
+	omDiscarded   = "grpc_bench_discarded_requests"
+	omErrors      = "grpc_bench_errors"
+	omRPS         = "grpc_bench_rps"
+	omIntendedRPS = "grpc_bench_intended_rps"
+	omDuration    = "grpc_bench_measured_seconds"
+	omLatency     = "grpc_bench_latency_seconds"
+	omQuantiles   = "grpc_bench_latency_quantile_seconds"
+	omTraffic     = "grpc_bench_traffic_bytes"
+	omCPU         = "grpc_bench_client_cpu_seconds"
+)
+
+// WriteOpenMetrics выводит итоги прогона в текстовом формате OpenMetrics:
+// по серии на результат с лейблами бенчмарка, этапа, цели, повтора и
+// параметров payload. Значения помечены временем окончания результата,
+// поэтому файл можно загрузить в Prometheus через
+// promtool tsdb create-blocks-from openmetrics.
+func WriteOpenMetrics(w io.Writer, report *Report) error {
+	om := &omWriter{w: bufio.NewWriter(w)}
+	results := report.Results
+
+	om.family(omRequests, "counter", "Requests of the measured phase by status")
+	for _, r := range results {
+		om.sample(r, omRequests+"_total", float64(r.Success), "status", "success")
+		om.sample(r, omRequests+"_total", float64(r.Failures), "status", "error")
+	}
+	om.family(omDiscarded, "counter", "Warm-up and cool-down requests excluded from the results")
+	for _, r := range results {
+		om.sample(r, omDiscarded+"_total", float64(r.Discarded))
+	}
+	om.family(omErrors, "counter", "Failed requests by gRPC code")
+	for _, r := range results {
+		codes := make([]string, 0, len(r.Errors))
+		for code := range r.Errors {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			om.sample(r, omErrors+"_total", float64(r.Errors[code]), "code", code)
+		}
+	}
+	om.family(omRPS, "gauge", "Successful requests per second of the measured phase")
+	for _, r := range results {
+		om.sample(r, omRPS, r.RPS)
+	}
+	om.family(omIntendedRPS, "gauge", "Target request rate of the open-loop scenario")
+	for _, r := range results {
+		if r.IntendedRPS > 0 {
+			om.sample(r, omIntendedRPS, r.IntendedRPS)
+		}
+	}
+	om.family(omDuration, "gauge", "Duration of the measured phase")
+	for _, r := range results {
+		om.sample(r, omDuration, r.Measured.Seconds())
+	}
+
+	om.family(omLatency, "histogram", "Latency distribution of successful requests")
+	for _, r := range results {
+		if r.Histogram == nil {
+			continue
+		}
+		for _, le := range prometheus.DefBuckets {
+			n := r.Histogram.countAtOrBelow(time.Duration(le * float64(time.Second)))
+			om.sample(r, omLatency+"_bucket", float64(n), "le", formatOMFloat(le))
+		}
+		om.sample(r, omLatency+"_bucket", float64(r.Histogram.Count()), "le", "+Inf")
+		om.sample(r, omLatency+"_count", float64(r.Histogram.Count()))
+		om.sample(r, omLatency+"_sum", r.Histogram.sum/float64(time.Second))
+	}
+	om.family(omQuantiles, "summary", "Latency percentiles of successful requests")
+	for _, r := range results {
+		for _, p := range r.Latency.Percentiles {
+			om.sample(r, omQuantiles, p.Value.Seconds(), "quantile", formatOMFloat(p.P/100))
+		}
+		om.sample(r, omQuantiles+"_count", float64(r.Latency.Count))
+		om.sample(r, omQuantiles+"_sum", r.Latency.Mean.Seconds()*float64(r.Latency.Count))
+	}
+
+	om.family(omTraffic, "counter", "Bytes of gRPC messages: wire after compression, raw before it")
+	for _, r := range results {
+		if t := r.Traffic; t != nil {
+			om.sample(r, omTraffic+"_total", float64(t.SentWire), "direction", "sent", "encoding", "wire")
+			om.sample(r, omTraffic+"_total", float64(t.SentRaw), "direction", "sent", "encoding", "raw")
+			om.sample(r, omTraffic+"_total", float64(t.ReceivedWire), "direction", "received", "encoding", "wire")
+			om.sample(r, omTraffic+"_total", float64(t.ReceivedRaw), "direction", "received", "encoding", "raw")
+		}
+	}
+	om.family(omCPU, "counter", "CPU time of the benchmark client during the run")
+	for _, r := range results {
+		if r.CPUTime > 0 {
+			om.sample(r, omCPU+"_total", r.CPUTime.Seconds())
+		}
+	}
+
+	om.printf("# EOF\n")
+	if om.err != nil {
+		return om.err
+	}
+	return om.w.Flush()
+}
+
+// omWriter пишет строки OpenMetrics и запоминает первую ошибку записи
+type omWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (om *omWriter) printf(format string, args ...interface{}) {
+	if om.err == nil {
+		_, om.err = fmt.Fprintf(om.w, format, args...)
+	}
+}
+
+func (om *omWriter) family(name, typ, help string) {
+	om.printf("# TYPE %s %s\n# HELP %s %s\n", name, typ, name, help)
+	if strings.HasSuffix(name, "_seconds") {
+		om.printf("# UNIT %s seconds\n", name)
+	} else if strings.HasSuffix(name, "_bytes") {
+		om.printf("# UNIT %s bytes\n", name)
+	}
+}
+
+// sample выводит значение результата r; extra — дополнительные пары лейблов
+func (om *omWriter) sample(r *Result, name string, v float64, extra ...string) {
+	labels := append(resultLabels(r), extra...)
+	parts := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		parts = append(parts, labels[i]+`="`+omEscaper.Replace(labels[i+1])+`"`)
+	}
+	om.printf("%s{%s} %s", name, strings.Join(parts, ","), formatOMFloat(v))
+	if !r.FinishedAt.IsZero() {
+		om.printf(" %s", strconv.FormatFloat(float64(r.FinishedAt.UnixMilli())/1000, 'f', -1, 64))
+	}
+	om.printf("\n")
+}
+
+// resultLabels — пары лейблов, которые отличают результат от остальных
+// результатов отчёта. Пустые значения пропускаются.
+func resultLabels(r *Result) []string {
+	labels := []string{"benchmark", r.Name}
+	add := func(name, value string) {
+		if value != "" {
+			labels = append(labels, name, value)
+		}
+	}
+	add("method", r.Method)
+	add("stage", r.Stage)
+	add("target", r.Target)
+	if r.Run > 0 {
+		add("run", strconv.Itoa(r.Run))
+	}
+	add("request_size", r.Params.RequestSize)
+	add("response_size", r.Params.ResponseSize)
+	add("payload_fill", r.Params.PayloadFill)
+	add("compression", r.Params.Compression)
+	return labels
+}
+
+// omEscaper экранирует значение лейбла по правилам OpenMetrics
+var omEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatOMFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
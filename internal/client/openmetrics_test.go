@@ -0,0 +1,107 @@
+package client
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestWriteOpenMetricsLatencyBuckets(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteOpenMetrics(&buf, sampleReport()); err != nil {
+		t.Fatal(err)
+	}
+	// Задержки 1..100ms: в бакет le попадают все значения до него включительно
+	want := map[string]float64{"0.005": 5, "0.01": 10, "0.025": 25, "0.05": 50, "0.1": 100, "0.25": 100, "10": 100, "+Inf": 100}
+	prev := -1.0
+	buckets := 0
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if !strings.HasPrefix(line, omLatency+"_bucket{") {
+			continue
+		}
+		buckets++
+		_, rest, _ := strings.Cut(line, `le="`)
+		le, rest, _ := strings.Cut(rest, `"`)
+		n, err := strconv.ParseFloat(strings.Fields(rest[1:])[0], 64)
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		if n < prev {
+			t.Errorf("бакет le=%s: %g меньше предыдущего %g", le, n, prev)
+		}
+		prev = n
+		if v, ok := want[le]; ok && v != n {
+			t.Errorf("бакет le=%s: %g, ожидается %g", le, n, v)
+		}
+	}
+	if buckets != len(prometheus.DefBuckets)+1 {
+		t.Errorf("бакетов %d, ожидается %d с +Inf", buckets, len(prometheus.DefBuckets)+1)
+	}
+	for _, sample := range []string{omLatency + "_count{", omLatency + "_sum{"} {
+		if !strings.Contains(buf.String(), sample) {
+			t.Errorf("нет %s", sample)
+		}
+	}
+}
+
+// Семейство начинается с TYPE и HELP, единица в суффиксе имени
+// объявляется UNIT, файл заканчивается # EOF
+func TestWriteOpenMetricsFormat(t *testing.T) {
+	report := sampleReport()
+	r := report.Results[0]
+	r.Stage = "a\"b\nc"
+	r.FinishedAt = time.Date(2026, 1, 2, 3, 4, 6, 500e6, time.UTC)
+	r.Traffic = &Traffic{SentWire: 10, SentRaw: 20}
+	var buf bytes.Buffer
+	if err := WriteOpenMetrics(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasSuffix(out, "\n# EOF\n") {
+		t.Errorf("файл не заканчивается # EOF:\n%s", out)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	family := ""
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case line == "# EOF":
+			if i != len(lines)-1 {
+				t.Errorf("# EOF в строке %d из %d", i+1, len(lines))
+			}
+		case fields[0] == "#" && fields[1] == "TYPE":
+			family = fields[2]
+			if !strings.HasPrefix(lines[i+1], "# HELP "+family+" ") {
+				t.Errorf("после TYPE %s нет HELP: %q", family, lines[i+1])
+			}
+			unit := ""
+			for _, u := range []string{"seconds", "bytes"} {
+				if strings.HasSuffix(family, "_"+u) {
+					unit = u
+				}
+			}
+			if hasUnit := strings.HasPrefix(lines[i+2], "# UNIT "); hasUnit != (unit != "") || hasUnit && lines[i+2] != "# UNIT "+family+" "+unit {
+				t.Errorf("семейство %s: строка единицы %q", family, lines[i+2])
+			}
+		case fields[0] == "#":
+		default:
+			if !strings.HasPrefix(line, family) {
+				t.Errorf("значение %q вне своего семейства %s", line, family)
+			}
+			if !strings.HasSuffix(line, " 1767323046.5") {
+				t.Errorf("значение без времени окончания результата: %q", line)
+			}
+		}
+	}
+	if !strings.Contains(out, `stage="a\"b\nc"`) {
+		t.Errorf("лейбл этапа не экранирован:\n%s", out)
+	}
+	if !strings.Contains(out, `grpc_bench_traffic_bytes_total{benchmark="UnaryPing",`) {
+		t.Errorf("нет трафика:\n%s", out)
+	}
+}
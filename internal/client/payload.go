@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/payload"
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// Виды содержимого payload
+const (
+	FillRandom = "random" // случайные байты, не сжимаются
+	FillText   = "text"   // текст из повторяющихся слов, хорошо сжимается
+)
+
+// PayloadFills — доступные виды содержимого payload
+var PayloadFills = []string{FillRandom, FillText}
+
+var payloadFills = map[string]pb.PayloadFill{
+	"":         pb.PayloadFill_PAYLOAD_RANDOM,
+	FillRandom: pb.PayloadFill_PAYLOAD_RANDOM,
+	FillText:   pb.PayloadFill_PAYLOAD_TEXT,
+}
+
+// Payload — содержимое сообщений, которые отправляет бенчмарк.
+// Пустой Message заменяется сообщением по умолчанию для RPC.
+type Payload struct {
+	Message      string `yaml:"message" json:"message,omitempty"`
+	Size         Size   `yaml:"size" json:"size"`                   // размер поля payload запроса
+	ResponseSize Size   `yaml:"response_size" json:"response_size"` // размер payload, который сервер вернёт в ответе
+	Fill         string `yaml:"fill" json:"fill,omitempty"`         // содержимое payload запроса и ответа: random или text
+}
+
+func (p Payload) validate() error {
+	if _, ok := payloadFills[p.Fill]; !ok {
+		return fmt.Errorf("неизвестное содержимое payload %q, доступны: random, text", p.Fill)
+	}
+	if n := max(p.Size.Max(), p.ResponseSize.Max()); n > payload.MaxSize {
+		return fmt.Errorf("размер payload %s больше максимального %s", FormatBytes(n), FormatBytes(payload.MaxSize))
+	}
+	return nil
+}
+
+// message возвращает текст сообщения; def — сообщение RPC по умолчанию
+func (p Payload) message(def string) string {
+	if p.Message != "" {
+		return p.Message
+	}
+	return def
+}
+
+// request собирает запрос с текстом msg и payload размеров из p
+func (p Payload) request(msg string) *pb.PingRequest {
+	fill := payloadFills[p.Fill]
+	return &pb.PingRequest{
+		Message:      msg,
+		Payload:      payload.Bytes(p.Size.next(), fill),
+		ResponseSize: uint32(p.ResponseSize.next()),
+		ResponseFill: fill,
+	}
+}
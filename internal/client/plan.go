@@ -0,0 +1,425 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+	"go.yaml.in/yaml/v2"
+)
+
+// RPCs — RPC сервиса, которые умеет нагружать клиент
+var RPCs = []string{"Ping", "StreamPing", "PushNotifications", "AggregatePing"}
+
+// Короткие синонимы имён RPC
+var rpcAliases = map[string]string{
+	"unary":     "Ping",
+	"stream":    "StreamPing",
+	"push":      "PushNotifications",
+	"aggregate": "AggregatePing",
+}
+
+// RPCName приводит имя RPC к каноническому, без учёта регистра
+func RPCName(name string) (string, error) {
+	if rpc, ok := rpcAliases[strings.ToLower(name)]; ok {
+		return rpc, nil
+	}
+	for _, rpc := range RPCs {
+		if strings.EqualFold(rpc, name) {
+			return rpc, nil
+		}
+	}
+	return "", fmt.Errorf("неизвестный RPC %q, доступны: %s", name, strings.Join(RPCs, ", "))
+}
+
+// RPCWorkload возвращает нагрузку для RPC с именем из RPCs
+func RPCWorkload(client pb.BenchmarkServiceClient, rpc string, opts LoadOptions) (Workload, error) {
+	switch rpc {
+	case "Ping":
+		return PingWorkload(client, opts.Payload), nil
+	case "StreamPing":
+		if opts.StreamWindow > 0 {
+			return LongStreamPingWorkload(client, opts.Payload, opts.Concurrency, opts.StreamWindow), nil
+		}
+		return StreamPingWorkload(client, opts.Payload), nil
+	case "PushNotifications":
+		return PushNotificationsWorkload(client, opts.Payload, opts.Push), nil
+	case "AggregatePing":
+		return AggregatePingWorkload(client, opts.Payload, opts.Batch), nil
+	}
+	return nil, fmt.Errorf("неизвестный RPC %q", rpc)
+}
+
+// Run запускает бенчмарк RPC с именем из RPCs
+func Run(client pb.BenchmarkServiceClient, rpc string, opts LoadOptions) (*Result, error) {
+	client = withCompression(client, opts.Compression)
+	switch rpc {
+	case "Ping":
+		return UnaryPing(client, opts)
+	case "StreamPing":
+		return StreamPing(client, opts)
+	case "PushNotifications":
+		return PushNotifications(client, opts)
+	case "AggregatePing":
+		return AggregatePing(client, opts)
+	}
+	return nil, fmt.Errorf("неизвестный RPC %q", rpc)
+}
+
+// Plan — план бенчмарка из YAML или JSON файла: цели, этапы и их SLO.
+// Пример:
+//
+//	count: 3
+//	targets:
+//	  - name: local
+//	    address: localhost:50051
+//	    tls: {ca: certs/ca.crt, cert: certs/client.crt, key: certs/client.key}
+//	defaults:
+//	  concurrency: 50
+//	  timeout: 5s
+//	stages:
+//	  - name: ping-ramp
+//	    rpc: Ping
+//	    scenario: open-loop
+//	    profile: ramp:from=10,to=500,duration=30s
+//	    duration: 30s
+//	    payload: {size: 256, response_size: 1KiB}
+//	  - name: ping-sizes
+//	    rpc: Ping
+//	    requests: 2000
+//	    sweep: {sizes: 64B..1MiB*4, side: both}
+//	    slo: [p99<20ms, error_rate<0.5%]
+type Plan struct {
+	Count    int          `yaml:"count" json:"count,omitempty"` // повторов всего плана
+	Targets  []PlanTarget `yaml:"targets" json:"targets,omitempty"`
+	Defaults StageSpec    `yaml:"defaults" json:"defaults,omitempty"`
+	Stages   []StageSpec  `yaml:"stages" json:"stages"`
+}
+
+// PlanTarget — сервер, на который подаётся нагрузка
+type PlanTarget struct {
+	Name    string     `yaml:"name" json:"name,omitempty"`
+	Address string     `yaml:"address" json:"address"`
+	TLS     *TLSConfig `yaml:"tls" json:"tls,omitempty"` // nil — настройки TLS из флагов клиента
+}
+
+// TLSConfig — настройки TLS подключения к цели
+type TLSConfig struct {
+	Insecure   bool   `yaml:"insecure" json:"insecure,omitempty"`
+	CA         string `yaml:"ca" json:"ca,omitempty"`
+	Cert       string `yaml:"cert" json:"cert,omitempty"`
+	Key        string `yaml:"key" json:"key,omitempty"`
+	ServerName string `yaml:"server_name" json:"server_name,omitempty"`
+}
+
+// StageSpec — этап плана в том виде, как он записан в файле.
+// Незаданные поля берутся из defaults плана, а затем из флагов клиента.
+type StageSpec struct {
+	Name               string             `yaml:"name" json:"name,omitempty"`
+	RPC                string             `yaml:"rpc" json:"rpc,omitempty"`
+	Targets            []string           `yaml:"targets" json:"targets,omitempty"` // имена целей; пусто — все цели
+	Scenario           string             `yaml:"scenario" json:"scenario,omitempty"`
+	Concurrency        int                `yaml:"concurrency" json:"concurrency,omitempty"`
+	Requests           int                `yaml:"requests" json:"requests,omitempty"`
+	Duration           string             `yaml:"duration" json:"duration,omitempty"`
+	WarmUp             string             `yaml:"warmup" json:"warmup,omitempty"`
+	CoolDown           string             `yaml:"cooldown" json:"cooldown,omitempty"`
+	RPS                float64            `yaml:"rps" json:"rps,omitempty"`
+	Profile            string             `yaml:"profile" json:"profile,omitempty"`
+	Timeout            string             `yaml:"timeout" json:"timeout,omitempty"`
+	RPCTimeouts        map[string]string  `yaml:"rpc_timeouts" json:"rpc_timeouts,omitempty"` // таймауты отдельных RPC вместо timeout
+	Interval           string             `yaml:"interval" json:"interval,omitempty"`
+	StreamWindow       int                `yaml:"stream_window" json:"stream_window,omitempty"`
+	BatchMessages      int                `yaml:"batch_messages" json:"batch_messages,omitempty"`
+	BatchGap           string             `yaml:"batch_gap" json:"batch_gap,omitempty"`
+	Push               *PushSpec          `yaml:"push" json:"push,omitempty"`
+	Payload            *Payload           `yaml:"payload" json:"payload,omitempty"`
+	Sweep              *SweepSpec         `yaml:"sweep" json:"sweep,omitempty"` // серия прогонов этапа по размеру payload
+	Compression        string             `yaml:"compression" json:"compression,omitempty"`
+	CompareCompression *CompressionSpec   `yaml:"compare_compression" json:"compare_compression,omitempty"` // прогоны этапа с разными алгоритмами сжатия
+	Mix                map[string]float64 `yaml:"mix" json:"mix,omitempty"`                                 // веса RPC смешанной нагрузки вместо rpc
+	SLO                []string           `yaml:"slo" json:"slo,omitempty"`
+
+	// Признаки этапа из уже развёрнутой серии; только для передачи этапа
+	// агенту (Stage.Spec), в плане не задаются
+	SweepSide         string `yaml:"-" json:"sweep_side,omitempty"`
+	CompressionSeries bool   `yaml:"-" json:"compression_series,omitempty"`
+}
+
+// PushSpec — параметры потока PushNotifications в плане
+type PushSpec struct {
+	Count        int    `yaml:"count" json:"count"`
+	Interval     string `yaml:"interval" json:"interval,omitempty"`
+	Distribution string `yaml:"distribution" json:"distribution,omitempty"`
+	PayloadSize  int    `yaml:"payload_size" json:"payload_size,omitempty"`
+}
+
+// SweepSpec — серия прогонов этапа по размеру payload в плане
+type SweepSpec struct {
+	Sizes string `yaml:"sizes" json:"sizes"`         // как у флага -sweep-sizes: "64,1KiB" или "64B..1MiB*4"
+	Side  string `yaml:"side" json:"side,omitempty"` // request, response или both; по умолчанию request
+}
+
+// CompressionSpec — сравнение алгоритмов сжатия в плане; пустой
+// список оставляет значение этапа
+type CompressionSpec struct {
+	Algorithms []string `yaml:"algorithms" json:"algorithms,omitempty"`
+	Fills      []string `yaml:"fills" json:"fills,omitempty"` // виды payload: random, text
+}
+
+// Stage — этап плана, готовый к запуску
+type Stage struct {
+	Name    string
+	RPC     string
+	Mix     []MixEntry // если задана, этап подаёт смешанную нагрузку вместо RPC
+	Targets []string
+	Load    LoadOptions
+	SLO     []SLO
+}
+
+// Run выполняет этап на одном сервере
+func (s Stage) Run(client pb.BenchmarkServiceClient) ([]*Result, error) {
+	if len(s.Mix) > 0 {
+		return Mix(client, s.Mix, s.Load)
+	}
+	res, err := Run(client, s.RPC, s.Load)
+	if err != nil {
+		return nil, err
+	}
+	return []*Result{res}, nil
+}
+
+// LoadPlan читает план из файла. Формат определяется расширением:
+// .json — JSON, остальные — YAML. Неизвестные поля считаются ошибкой.
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plan Plan
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&plan)
+	} else {
+		err = yaml.UnmarshalStrict(data, &plan)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(plan.Stages) == 0 {
+		return nil, fmt.Errorf("%s: в плане нет этапов", path)
+	}
+	return &plan, nil
+}
+
+// Build проверяет план и собирает этапы; defaults — параметры нагрузки
+// из флагов клиента, которые этапы и defaults плана переопределяют
+func (p *Plan) Build(defaults LoadOptions) ([]Stage, error) {
+	targets := map[string]bool{}
+	for i, t := range p.Targets {
+		if t.Address == "" {
+			return nil, fmt.Errorf("цель #%d: не задан address", i+1)
+		}
+		targets[t.TargetName()] = true
+	}
+
+	base := defaults
+	if err := p.Defaults.apply(&base); err != nil {
+		return nil, fmt.Errorf("defaults: %v", err)
+	}
+	baseSLO, err := parseSLOs(p.Defaults.SLO)
+	if err != nil {
+		return nil, fmt.Errorf("defaults: %v", err)
+	}
+
+	stages := make([]Stage, 0, len(p.Stages))
+	for i, spec := range p.Stages {
+		name := spec.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		st := Stage{Name: spec.Name, Targets: spec.Targets, Load: base}
+		switch {
+		case len(spec.Mix) > 0 && spec.RPC != "":
+			return nil, fmt.Errorf("этап %s: rpc и mix взаимоисключающие", name)
+		case len(spec.Mix) > 0:
+			st.Mix, err = newMix(spec.Mix)
+		default:
+			rpc := spec.RPC
+			if rpc == "" {
+				rpc = p.Defaults.RPC
+			}
+			st.RPC, err = RPCName(rpc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("этап %s: %v", name, err)
+		}
+		for _, t := range spec.Targets {
+			if !targets[t] {
+				return nil, fmt.Errorf("этап %s: неизвестная цель %q", name, t)
+			}
+		}
+		if err := spec.apply(&st.Load); err != nil {
+			return nil, fmt.Errorf("этап %s: %v", name, err)
+		}
+		if err := st.Load.validate(); err != nil {
+			return nil, fmt.Errorf("этап %s: %v", name, err)
+		}
+		slos, err := parseSLOs(spec.SLO)
+		if err != nil {
+			return nil, fmt.Errorf("этап %s: %v", name, err)
+		}
+		st.SLO = append(append([]SLO(nil), baseSLO...), slos...)
+
+		series, err := p.series(spec, st)
+		if err != nil {
+			return nil, fmt.Errorf("этап %s: %v", name, err)
+		}
+		stages = append(stages, series...)
+	}
+	return stages, nil
+}
+
+// series размножает этап по серии размеров sweep и сравнению сжатия
+// compare_compression этапа или defaults плана
+func (p *Plan) series(spec StageSpec, st Stage) ([]Stage, error) {
+	stages := []Stage{st}
+	sweep := spec.Sweep
+	if sweep == nil {
+		sweep = p.Defaults.Sweep
+	}
+	if sweep != nil {
+		sizes, err := ParseSweepSizes(sweep.Sizes)
+		if err != nil {
+			return nil, fmt.Errorf("sweep: %v", err)
+		}
+		if stages, err = Sweep(st, sizes, sweep.Side); err != nil {
+			return nil, fmt.Errorf("sweep: %v", err)
+		}
+	}
+
+	cmp := spec.CompareCompression
+	if cmp == nil {
+		cmp = p.Defaults.CompareCompression
+	}
+	if cmp == nil {
+		return stages, nil
+	}
+	var out []Stage
+	for _, s := range stages {
+		series, err := CompareCompression(s, cmp.Algorithms, cmp.Fills)
+		if err != nil {
+			return nil, fmt.Errorf("compare_compression: %v", err)
+		}
+		out = append(out, series...)
+	}
+	return out, nil
+}
+
+// TargetName — имя цели в плане; по умолчанию адрес
+func (t PlanTarget) TargetName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Address
+}
+
+// apply переносит заданные в спецификации поля в opts
+func (s StageSpec) apply(opts *LoadOptions) error {
+	if s.Scenario != "" {
+		scenario, err := ParseScenario(s.Scenario)
+		if err != nil {
+			return err
+		}
+		opts.Scenario = scenario
+	}
+	if s.Concurrency != 0 {
+		opts.Concurrency = s.Concurrency
+	}
+	if s.Requests != 0 {
+		// этап с числом запросов не наследует длительность из флагов
+		opts.Requests, opts.Duration = s.Requests, 0
+	}
+	if s.StreamWindow != 0 {
+		opts.StreamWindow = s.StreamWindow
+	}
+	if s.BatchMessages != 0 {
+		opts.Batch.Messages = s.BatchMessages
+	}
+	if s.RPS != 0 {
+		opts.TargetRPS = s.RPS
+	}
+	if s.Profile != "" {
+		profile, target, err := ParseProfile(s.Profile)
+		if err != nil {
+			return err
+		}
+		opts.Profile, opts.ProfileTarget = profile, target
+	}
+	if s.Payload != nil {
+		opts.Payload = *s.Payload
+	}
+	if s.Compression != "" {
+		opts.Compression = s.Compression
+	}
+	if len(s.RPCTimeouts) > 0 {
+		timeouts, err := newRPCTimeouts(s.RPCTimeouts)
+		if err != nil {
+			return err
+		}
+		opts.RPCTimeouts = timeouts
+	}
+	if s.Push != nil {
+		opts.Push = PushStream{Count: s.Push.Count, Distribution: s.Push.Distribution, PayloadSize: s.Push.PayloadSize}
+	}
+
+	durations := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"duration", s.Duration, &opts.Duration},
+		{"warmup", s.WarmUp, &opts.WarmUp},
+		{"cooldown", s.CoolDown, &opts.CoolDown},
+		{"timeout", s.Timeout, &opts.Timeout},
+		{"interval", s.Interval, &opts.Interval},
+		{"batch_gap", s.BatchGap, &opts.Batch.Gap},
+		{"push.interval", s.pushInterval(), &opts.Push.Interval},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", d.name, err)
+		}
+		*d.dst = v
+	}
+	return nil
+}
+
+func (s StageSpec) pushInterval() string {
+	if s.Push == nil {
+		return ""
+	}
+	return s.Push.Interval
+}
+
+func parseSLOs(specs []string) ([]SLO, error) {
+	slos := make([]SLO, 0, len(specs))
+	for _, spec := range specs {
+		slo, err := ParseSLO(spec)
+		if err != nil {
+			return nil, err
+		}
+		slos = append(slos, slo)
+	}
+	return slos, nil
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRPCName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Ping", "Ping"},
+		{"streamping", "StreamPing"},
+		{"unary", "Ping"},
+		{"Stream", "StreamPing"},
+		{"push", "PushNotifications"},
+		{"aggregate", "AggregatePing"},
+		{"Stats", ""},
+	}
+	for _, tt := range tests {
+		got, err := RPCName(tt.name)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("RPCName(%q) = %q, %v; ожидается %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+// Параметры этапа берутся из флагов, поверх них — defaults плана,
+// поверх них — поля самого этапа
+func TestPlanBuild(t *testing.T) {
+	flags := LoadOptions{Scenario: ScenarioConstant, Concurrency: 4, Duration: 10 * time.Second}
+	plan := &Plan{
+		Targets: []PlanTarget{{Name: "old", Address: "a:1"}, {Address: "b:2"}},
+		Defaults: StageSpec{
+			RPC:     "unary",
+			WarmUp:  "1s",
+			Timeout: "200ms",
+			SLO:     []string{"p99<20ms"},
+		},
+		Stages: []StageSpec{
+			{Name: "defaults"},
+			{Name: "requests", RPC: "stream", Concurrency: 8, Requests: 100, Targets: []string{"b:2"}, SLO: []string{"error_rate<1%"}},
+			{Name: "mix", Mix: map[string]float64{"Ping": 3, "push": 1}, Timeout: "1s"},
+		},
+	}
+	stages, err := plan.Build(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stages) != 3 {
+		t.Fatalf("этапов %d, ожидается 3", len(stages))
+	}
+
+	st := stages[0]
+	if st.RPC != "Ping" || st.Load.Concurrency != 4 || st.Load.Duration != 10*time.Second ||
+		st.Load.WarmUp != time.Second || st.Load.Timeout != 200*time.Millisecond || len(st.SLO) != 1 || st.Targets != nil {
+		t.Errorf("этап defaults: %+v", st)
+	}
+
+	st = stages[1]
+	if st.RPC != "StreamPing" || st.Load.Concurrency != 8 || st.Load.Requests != 100 || st.Load.Duration != 0 {
+		t.Errorf("этап requests: rpc %s, concurrency %d, requests %d, duration %v", st.RPC, st.Load.Concurrency, st.Load.Requests, st.Load.Duration)
+	}
+	if len(st.SLO) != 2 || len(st.Targets) != 1 || st.Targets[0] != "b:2" {
+		t.Errorf("этап requests: slo %v, цели %v", st.SLO, st.Targets)
+	}
+
+	st = stages[2]
+	if st.RPC != "" || FormatMix(st.Mix) != "Ping=3,PushNotifications=1" || st.Load.Timeout != time.Second {
+		t.Errorf("этап mix: rpc %q, смесь %s, timeout %v", st.RPC, FormatMix(st.Mix), st.Load.Timeout)
+	}
+}
+
+func TestPlanBuildErrors(t *testing.T) {
+	flags := LoadOptions{Scenario: ScenarioConstant, Concurrency: 1, Requests: 10}
+	tests := []struct {
+		name string
+		plan Plan
+		err  string
+	}{
+		{"цель без адреса", Plan{Targets: []PlanTarget{{Name: "a"}}, Stages: []StageSpec{{RPC: "Ping"}}}, "цель #1"},
+		{"неизвестный RPC", Plan{Stages: []StageSpec{{RPC: "Stats"}}}, "этап #1: неизвестный RPC"},
+		{"rpc и mix", Plan{Stages: []StageSpec{{Name: "x", RPC: "Ping", Mix: map[string]float64{"Ping": 1}}}}, "этап x: rpc и mix"},
+		{"неизвестная цель", Plan{Targets: []PlanTarget{{Address: "a:1"}}, Stages: []StageSpec{{RPC: "Ping", Targets: []string{"b:2"}}}}, "неизвестная цель"},
+		{"неверная длительность", Plan{Stages: []StageSpec{{RPC: "Ping", WarmUp: "1"}}}, "warmup"},
+		{"ошибка в defaults", Plan{Defaults: StageSpec{Scenario: "burst"}, Stages: []StageSpec{{RPC: "Ping"}}}, "defaults"},
+		{"некорректная нагрузка", Plan{Stages: []StageSpec{{RPC: "Ping", Scenario: "open-loop"}}}, "TargetRPS"},
+		{"неверный SLO", Plan{Stages: []StageSpec{{RPC: "Ping", SLO: []string{"p99"}}}}, "этап #1"},
+		{"неверная серия", Plan{Stages: []StageSpec{{RPC: "Ping", Sweep: &SweepSpec{Sizes: "64", Side: "up"}}}}, "sweep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.plan.Build(flags)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ошибка %v, ожидается с %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// Серии sweep и compare_compression этапа или defaults разворачиваются
+// в отдельные этапы: сначала по размеру, затем по сжатию
+func TestPlanSeries(t *testing.T) {
+	flags := LoadOptions{Scenario: ScenarioConstant, Concurrency: 1, Requests: 10}
+	plan := &Plan{
+		Defaults: StageSpec{RPC: "Ping", CompareCompression: &CompressionSpec{Algorithms: []string{"identity", "gzip"}}},
+		Stages: []StageSpec{
+			{Name: "sizes", Sweep: &SweepSpec{Sizes: "64,1KiB", Side: SweepBoth}},
+			{Name: "single"},
+		},
+	}
+	stages, err := plan.Build(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, st := range stages {
+		got = append(got, st.Name+"/"+st.Load.Payload.Size.String()+"/"+st.Load.Compression)
+	}
+	want := []string{"sizes/64B/identity", "sizes/64B/gzip", "sizes/1KiB/identity", "sizes/1KiB/gzip", "single//identity", "single//gzip"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("этапы %v, ожидается %v", got, want)
+	}
+	for _, st := range stages {
+		if !st.Load.CompressionSeries || (st.Name == "sizes") != (st.Load.Sweep == SweepBoth) {
+			t.Errorf("этап %s: признаки серий sweep %q, сжатие %v", st.Name, st.Load.Sweep, st.Load.CompressionSeries)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package client
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProfileTarget — величина, которую задаёт профиль нагрузки
+type ProfileTarget string
+
+const (
+	// ProfileRPS — профиль задаёт целевую частоту запросов (open-loop)
+	ProfileRPS ProfileTarget = "rps"
+	// ProfileConcurrency — профиль задаёт число активных воркеров (closed-loop)
+	ProfileConcurrency ProfileTarget = "concurrency"
+)
+
+// Profile — форма нагрузки во времени. At возвращает целевое значение
+// (RPS или число воркеров) через elapsed после начала прогона.
+type Profile interface {
+	At(elapsed time.Duration) float64
+}
+
+// ProfileFunc позволяет задать профиль обычной функцией
+type ProfileFunc func(elapsed time.Duration) float64
+
+func (f ProfileFunc) At(elapsed time.Duration) float64 { return f(elapsed) }
+
+func (f ProfileFunc) String() string { return "func" }
+
+// ConstantProfile — постоянная нагрузка
+type ConstantProfile struct {
+	Value float64
+}
+
+func (p ConstantProfile) At(time.Duration) float64 { return p.Value }
+
+func (p ConstantProfile) String() string { return fmt.Sprintf("constant:value=%g", p.Value) }
+
+// RampProfile — линейное изменение от From до To за Duration, дальше держится To.
+// Если To < From, получается плавный спад нагрузки.
+type RampProfile struct {
+	From, To float64
+	Duration time.Duration
+}
+
+func (p RampProfile) At(elapsed time.Duration) float64 {
+	if elapsed >= p.Duration || p.Duration <= 0 {
+		return p.To
+	}
+	return p.From + (p.To-p.From)*float64(elapsed)/float64(p.Duration)
+}
+
+func (p RampProfile) String() string {
+	return fmt.Sprintf("ramp:from=%g,to=%g,duration=%s", p.From, p.To, p.Duration)
+}
+
+// StepProfile — лестница: старт со Start, каждые Every прибавляется Step,
+// всего Steps ступеней
+type StepProfile struct {
+	Start, Step float64
+	Every       time.Duration
+	Steps       int
+}
+
+func (p StepProfile) At(elapsed time.Duration) float64 {
+	if p.Every <= 0 {
+		return p.Start
+	}
+	n := int(elapsed / p.Every)
+	if n > p.Steps {
+		n = p.Steps
+	}
+	return p.Start + p.Step*float64(n)
+}
+
+func (p StepProfile) String() string {
+	return fmt.Sprintf("steps:start=%g,step=%g,every=%s,count=%d", p.Start, p.Step, p.Every, p.Steps)
+}
+
+// SpikeProfile — базовая нагрузка Base с всплеском до Peak
+// в интервале [Start, Start+Length)
+type SpikeProfile struct {
+	Base, Peak    float64
+	Start, Length time.Duration
+}
+
+func (p SpikeProfile) At(elapsed time.Duration) float64 {
+	if elapsed >= p.Start && elapsed < p.Start+p.Length {
+		return p.Peak
+	}
+	return p.Base
+}
+
+func (p SpikeProfile) String() string {
+	return fmt.Sprintf("spike:base=%g,peak=%g,at=%s,length=%s", p.Base, p.Peak, p.Start, p.Length)
+}
+
+// SineProfile — синусоида вокруг Base с амплитудой Amplitude и периодом Period
+type SineProfile struct {
+	Base, Amplitude float64
+	Period          time.Duration
+}
+
+func (p SineProfile) At(elapsed time.Duration) float64 {
+	if p.Period <= 0 {
+		return p.Base
+	}
+	v := p.Base + p.Amplitude*math.Sin(2*math.Pi*float64(elapsed)/float64(p.Period))
+	return math.Max(v, 0)
+}
+
+func (p SineProfile) String() string {
+	return fmt.Sprintf("sine:base=%g,amplitude=%g,period=%s", p.Base, p.Amplitude, p.Period)
+}
+
+// ProfilePoint — опорная точка кусочно-линейного профиля
+type ProfilePoint struct {
+	At    time.Duration
+	Value float64
+}
+
+// PiecewiseProfile — произвольная форма, заданная опорными точками.
+// Между точками значение интерполируется линейно, до первой и после
+// последней точки держится крайнее значение.
+type PiecewiseProfile struct {
+	Points []ProfilePoint
+}
+
+func (p PiecewiseProfile) At(elapsed time.Duration) float64 {
+	pts := p.Points
+	if len(pts) == 0 {
+		return 0
+	}
+	if elapsed <= pts[0].At {
+		return pts[0].Value
+	}
+	for i := 1; i < len(pts); i++ {
+		if elapsed < pts[i].At {
+			a, b := pts[i-1], pts[i]
+			return a.Value + (b.Value-a.Value)*float64(elapsed-a.At)/float64(b.At-a.At)
+		}
+	}
+	return pts[len(pts)-1].Value
+}
+
+func (p PiecewiseProfile) String() string {
+	parts := make([]string, len(p.Points))
+	for i, pt := range p.Points {
+		parts[i] = fmt.Sprintf("%s=%g", pt.At, pt.Value)
+	}
+	return "piecewise:" + strings.Join(parts, ",")
+}
+
+// profileTail — профиль, который с момента end держит постоянное значение
+// final. ok=false, если профиль не выходит на постоянное значение.
+type profileTail interface {
+	tail() (end time.Duration, final float64, ok bool)
+}
+
+func (p ConstantProfile) tail() (time.Duration, float64, bool) { return 0, p.Value, true }
+
+func (p RampProfile) tail() (time.Duration, float64, bool) { return max(p.Duration, 0), p.To, true }
+
+func (p StepProfile) tail() (time.Duration, float64, bool) {
+	if p.Every <= 0 {
+		return 0, p.Start, true
+	}
+	return p.Every * time.Duration(max(p.Steps, 0)), p.Start + p.Step*float64(max(p.Steps, 0)), true
+}
+
+func (p SpikeProfile) tail() (time.Duration, float64, bool) {
+	return max(p.Start+p.Length, 0), p.Base, true
+}
+
+func (p SineProfile) tail() (time.Duration, float64, bool) {
+	switch {
+	case p.Period <= 0:
+		return 0, p.Base, true
+	case p.Base+math.Abs(p.Amplitude) <= 0:
+		return 0, 0, true
+	}
+	return 0, 0, false
+}
+
+func (p PiecewiseProfile) tail() (time.Duration, float64, bool) {
+	if len(p.Points) == 0 {
+		return 0, 0, true
+	}
+	last := p.Points[len(p.Points)-1]
+	return max(last.At, 0), last.Value, true
+}
+
+// profileEnd — момент, после которого профиль p держит нулевую нагрузку;
+// ok=false, если такого момента нет или он неизвестен (ProfileFunc)
+func profileEnd(p Profile) (end time.Duration, ok bool) {
+	t, isTail := p.(profileTail)
+	if !isTail {
+		return 0, false
+	}
+	end, final, ok := t.tail()
+	if !ok || final > 0 {
+		return 0, false
+	}
+	return end, true
+}
+
+// profileRequests — сколько запросов выдаст RPS-профиль p начиная с from,
+// если он заканчивается нулевой нагрузкой; ok=false, если профиль не
+// заканчивается и выдаёт запросы бесконечно
+func profileRequests(p Profile, from time.Duration) (n float64, ok bool) {
+	end, ok := profileEnd(p)
+	if !ok {
+		return 0, false
+	}
+	const step = time.Millisecond
+	for at := from; at < end; at += step {
+		n += math.Max(p.At(at), 0) * step.Seconds()
+	}
+	return n, true
+}
+
+// ParseProfile разбирает профиль из строки конфигурации вида
+// "<тип>:<параметр>=<значение>,...". Параметр target (rps или concurrency)
+// задаёт, к чему применяется профиль; по умолчанию rps. Примеры:
+//
+//	constant:value=200
+//	ramp:from=10,to=500,duration=30s
+//	steps:start=100,step=100,every=10s,count=5
+//	spike:base=100,peak=1000,at=20s,length=5s
+//	sine:base=300,amplitude=200,period=1m
+//	piecewise:0s=10,10s=200,40s=200,50s=0
+//	ramp:from=1,to=64,duration=1m,target=concurrency
+func ParseProfile(spec string) (Profile, ProfileTarget, error) {
+	kind, rest, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	params := map[string]string{}
+	if rest != "" {
+		for _, kv := range strings.Split(rest, ",") {
+			k, v, ok := strings.Cut(strings.TrimSpace(kv), "=")
+			if !ok {
+				return nil, "", fmt.Errorf("профиль %q: ожидается параметр=значение, получено %q", spec, kv)
+			}
+			params[k] = v
+		}
+	}
+
+	target := ProfileRPS
+	if t, ok := params["target"]; ok {
+		target = ProfileTarget(t)
+		delete(params, "target")
+		if target != ProfileRPS && target != ProfileConcurrency {
+			return nil, "", fmt.Errorf("профиль %q: неизвестный target %q", spec, t)
+		}
+	}
+
+	p := profileParams{spec: spec, values: params}
+	var profile Profile
+	switch kind {
+	case "constant":
+		profile = ConstantProfile{Value: p.float("value")}
+	case "ramp":
+		profile = RampProfile{From: p.float("from"), To: p.float("to"), Duration: p.duration("duration")}
+	case "steps":
+		profile = StepProfile{Start: p.float("start"), Step: p.float("step"), Every: p.duration("every"), Steps: int(p.float("count"))}
+	case "spike":
+		profile = SpikeProfile{Base: p.float("base"), Peak: p.float("peak"), Start: p.duration("at"), Length: p.duration("length")}
+	case "sine":
+		profile = SineProfile{Base: p.float("base"), Amplitude: p.float("amplitude"), Period: p.duration("period")}
+	case "piecewise":
+		var pts []ProfilePoint
+		for k := range params {
+			at, err := time.ParseDuration(k)
+			if err != nil {
+				return nil, "", fmt.Errorf("профиль %q: неверное время точки %q", spec, k)
+			}
+			pts = append(pts, ProfilePoint{At: at, Value: p.float(k)})
+		}
+		sort.Slice(pts, func(i, j int) bool { return pts[i].At < pts[j].At })
+		profile = PiecewiseProfile{Points: pts}
+	default:
+		return nil, "", fmt.Errorf("неизвестный тип профиля %q", kind)
+	}
+	if p.err != nil {
+		return nil, "", p.err
+	}
+	return profile, target, nil
+}
+
+// profileParams — разбор параметров профиля с запоминанием первой ошибки
+type profileParams struct {
+	spec   string
+	values map[string]string
+	err    error
+}
+
+func (p *profileParams) float(key string) float64 {
+	s, ok := p.values[key]
+	if !ok {
+		p.fail(fmt.Errorf("профиль %q: не задан параметр %s", p.spec, key))
+		return 0
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		p.fail(fmt.Errorf("профиль %q: параметр %s: %v", p.spec, key, err))
+	}
+	return v
+}
+
+func (p *profileParams) duration(key string) time.Duration {
+	s, ok := p.values[key]
+	if !ok {
+		p.fail(fmt.Errorf("профиль %q: не задан параметр %s", p.spec, key))
+		return 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		p.fail(fmt.Errorf("профиль %q: параметр %s: %v", p.spec, key, err))
+	}
+	return d
+}
+
+func (p *profileParams) fail(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
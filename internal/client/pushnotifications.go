@@ -2,28 +2,218 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/go-portfolio/go-grpc-benchmark/internal/payload"
 	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
 )
 
-func PushNotifications(client pb.BenchmarkServiceClient, startMsg string) {
+// Метрики PushNotifications в Result.Metrics
+const (
+	MetricFirstMessage = "time_to_first_message" // от открытия потока до первого сообщения
+	MetricInterArrival = "inter_arrival"         // интервал между соседними сообщениями потока
+	MetricJitter       = "jitter"                // разница соседних интервалов между сообщениями
+)
+
+// PushStream — параметры серверного потока, которые клиент передаёт
+// в запросе PushNotifications. При нулевом Count параметры не передаются
+// и сервер отправляет поток по умолчанию.
+type PushStream struct {
+	Count        int           `json:"count"`                  // сообщений в потоке
+	Interval     time.Duration `json:"interval_ns,omitempty"`  // интервал перед каждым сообщением, среднее для случайных
+	Distribution string        `json:"distribution,omitempty"` // fixed, uniform, exponential; пусто — fixed
+	PayloadSize  int           `json:"payload_size,omitempty"` // размер payload каждого сообщения ответа в байтах
+}
+
+// PushDistributions — распределения интервала между сообщениями потока
+var PushDistributions = map[string]pb.IntervalDistribution{
+	"fixed":       pb.IntervalDistribution_INTERVAL_FIXED,
+	"uniform":     pb.IntervalDistribution_INTERVAL_UNIFORM,
+	"exponential": pb.IntervalDistribution_INTERVAL_EXPONENTIAL,
+}
+
+func (p PushStream) validate() error {
+	if p.Count < 0 || p.Interval < 0 || p.PayloadSize < 0 {
+		return errors.New("параметры потока PushNotifications не могут быть отрицательными")
+	}
+	if p.PayloadSize > payload.MaxSize {
+		return fmt.Errorf("размер payload потока PushNotifications %s больше максимального %s", FormatBytes(p.PayloadSize), FormatBytes(payload.MaxSize))
+	}
+	if _, ok := PushDistributions[p.distribution()]; !ok {
+		return fmt.Errorf("неизвестное распределение интервала %q, доступны: fixed, uniform, exponential", p.Distribution)
+	}
+	return nil
+}
+
+func (p PushStream) distribution() string {
+	if p.Distribution == "" {
+		return "fixed"
+	}
+	return p.Distribution
+}
+
+// params — параметры потока для запроса; nil — поток по умолчанию сервера
+func (p PushStream) params() *pb.StreamParams {
+	if p.Count == 0 {
+		return nil
+	}
+	return &pb.StreamParams{
+		Count:        uint32(p.Count),
+		IntervalUs:   uint64(p.Interval / time.Microsecond),
+		Distribution: PushDistributions[p.distribution()],
+		PayloadSize:  uint32(p.PayloadSize),
+	}
+}
+
+// String возвращает параметры в виде "count=100,interval=1ms,distribution=fixed,payload=0"
+func (p PushStream) String() string {
+	return strings.Join([]string{
+		"count=" + strconv.Itoa(p.Count),
+		"interval=" + p.Interval.String(),
+		"distribution=" + p.distribution(),
+		"payload=" + strconv.Itoa(p.PayloadSize),
+	}, ",")
+}
+
+// PushNotifications — бенчмарк серверных потоков: opts.Concurrency
+// подписчиков, каждый открывает поток и читает его до конца, затем
+// открывает следующий. Latency — время от открытия потока до его
+// завершения; сообщения и завершение потоков — в Result.Streams.
+func PushNotifications(client pb.BenchmarkServiceClient, opts LoadOptions) (*Result, error) {
 	log.Println("=== Server Streaming: PushNotifications ===")
-	stream, err := client.PushNotifications(context.Background(), &pb.PingRequest{Message: startMsg})
+	return RunWorkload(PushNotificationsWorkload(client, opts.Payload, opts.Push), opts)
+}
+
+// PushNotificationsWorkload — серверные потоки с параметрами stream,
+// прочитанные до конца. Подписчик — воркер; кроме задержки итерации
+// собираются метрики MetricFirstMessage, MetricInterArrival и MetricJitter.
+func PushNotificationsWorkload(client pb.BenchmarkServiceClient, payload Payload, stream PushStream) Workload {
+	return &pushNotifications{client: client, payload: payload, stream: stream.params(), subs: map[int]*SubscriberStats{}}
+}
+
+type pushNotifications struct {
+	client  pb.BenchmarkServiceClient
+	payload Payload
+	stream  *pb.StreamParams
+	metrics MetricSet
+
+	mu                        sync.Mutex // защищает поля ниже
+	subs                      map[int]*SubscriberStats
+	completed, failed, broken int64
+}
+
+func (w *pushNotifications) Name() string { return "PushNotifications" }
+func (w *pushNotifications) Method() string {
+	return pb.BenchmarkService_PushNotifications_FullMethodName
+}
+
+func (w *pushNotifications) Setup(context.Context) error    { return nil }
+func (w *pushNotifications) Teardown(context.Context) error { return nil }
+
+func (w *pushNotifications) Metrics() map[string]*Histogram {
+	return w.metrics.Histograms()
+}
+
+func (w *pushNotifications) Execute(ctx context.Context, it Iteration) error {
+	start := time.Now()
+	req := w.payload.request(w.payload.message("start"))
+	req.Stream = w.stream
+	stream, err := w.client.PushNotifications(ctx, req)
 	if err != nil {
-		log.Fatalf("Ошибка PushNotifications: %v", err)
+		log.Printf("Worker %d: Ошибка PushNotifications: %v", it.Worker, err)
+		w.streamDone(it.Worker, 0, err)
+		return WithPhase(PhaseDial, err)
 	}
+
+	var messages int64
+	var last time.Time
+	var prevGap time.Duration
 	for {
 		resp, err := stream.Recv()
 		if err == io.EOF {
-			LogDebug("PushNotifications: поток завершён")
-			break
+			LogDebug("Worker %d: PushNotifications: поток завершён, сообщений: %d", it.Worker, messages)
+			w.streamDone(it.Worker, messages, nil)
+			return nil
 		}
 		if err != nil {
-			log.Printf("Ошибка получения PushNotifications: %v", err)
-			break
+			log.Printf("Worker %d: Ошибка получения PushNotifications: %v", it.Worker, err)
+			w.streamDone(it.Worker, messages, err)
+			return WithPhase(PhaseRecv, err)
 		}
-		LogDebug("PushNotifications response: %s", resp.Message)
+		now := time.Now()
+		messages++
+		switch {
+		case !it.Measured:
+			// подписчик разогрева или остывания: сообщения считаются, а
+			// распределения времени — только для измеряемых подписчиков
+		case messages == 1:
+			w.metrics.Record(MetricFirstMessage, now.Sub(start))
+		default:
+			gap := now.Sub(last)
+			w.metrics.Record(MetricInterArrival, gap)
+			if messages > 2 {
+				w.metrics.Record(MetricJitter, (gap - prevGap).Abs())
+			}
+			prevGap = gap
+		}
+		last = now
+		LogDebug("Worker %d: PushNotifications response: %s", it.Worker, resp.Message)
+	}
+}
+
+// streamDone учитывает завершённый поток подписчика
+func (w *pushNotifications) streamDone(worker int, messages int64, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sub, ok := w.subs[worker]
+	if !ok {
+		sub = &SubscriberStats{Subscriber: worker}
+		w.subs[worker] = sub
+	}
+	sub.Streams++
+	sub.Messages += messages
+	switch {
+	case err == nil:
+		w.completed++
+	case messages > 0:
+		w.failed++
+		w.broken++
+	default:
+		w.failed++
 	}
 }
+
+// Report добавляет в результат статистику сообщений по подписчикам
+func (w *pushNotifications) Report(res *Result) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	st := &StreamStats{Completed: w.completed, Failed: w.failed, Broken: w.broken}
+	for _, sub := range w.subs {
+		s := *sub
+		if res.Elapsed > 0 {
+			s.MessagesPerSec = float64(s.Messages) / res.Elapsed.Seconds()
+		}
+		st.Messages += s.Messages
+		st.Subscribers = append(st.Subscribers, s)
+	}
+	sort.Slice(st.Subscribers, func(i, j int) bool {
+		return st.Subscribers[i].Subscriber < st.Subscribers[j].Subscriber
+	})
+	if res.Elapsed > 0 {
+		st.MessagesPerSec = float64(st.Messages) / res.Elapsed.Seconds()
+	}
+	res.Streams = st
+}
+
+var _ interface {
+	MetricsWorkload
+	ReportWorkload
+} = (*pushNotifications)(nil)
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/payload"
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// pushServer отправляет в потоке Stream.Count сообщений; поток с
+// сообщением "break" обрывается ошибкой после них, "fail" — до них
+type pushServer struct {
+	echoServer
+}
+
+func (pushServer) PushNotifications(req *pb.PingRequest, stream pb.BenchmarkService_PushNotificationsServer) error {
+	if req.Message == "fail" {
+		return status.Error(codes.Unavailable, "fail")
+	}
+	for i := uint32(0); i < req.Stream.GetCount(); i++ {
+		if err := stream.Send(&pb.PingResponse{}); err != nil {
+			return err
+		}
+	}
+	if req.Message == "break" {
+		return status.Error(codes.Internal, "break")
+	}
+	return nil
+}
+
+func TestPushNotificationsStreams(t *testing.T) {
+	const streams, messages = 6, 4
+	tests := []struct {
+		name                      string
+		message                   string
+		completed, failed, broken int64
+		delivered                 int64 // сообщений всего
+	}{
+		{"потоки дочитаны", "", streams, 0, 0, streams * messages},
+		{"потоки оборваны", "break", 0, streams, streams, streams * messages},
+		{"потоки не открылись", "fail", 0, streams, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := pb.NewBenchmarkServiceClient(benchConn(t, pushServer{}))
+			w := PushNotificationsWorkload(client, Payload{Message: tt.message}, PushStream{Count: messages})
+			res, err := RunWorkload(w, LoadOptions{Scenario: ScenarioConstant, Concurrency: 2, Requests: streams})
+			if err != nil {
+				t.Fatal(err)
+			}
+			st := res.Streams
+			if st == nil {
+				t.Fatal("нет статистики потоков")
+			}
+			if st.Completed != tt.completed || st.Failed != tt.failed || st.Broken != tt.broken || st.Messages != tt.delivered {
+				t.Errorf("потоки: завершено %d, ошибок %d, оборвано %d, сообщений %d", st.Completed, st.Failed, st.Broken, st.Messages)
+			}
+			if len(st.Subscribers) != 2 || st.Subscribers[0].Subscriber != 0 || st.Subscribers[1].Subscriber != 1 {
+				t.Fatalf("подписчики %+v", st.Subscribers)
+			}
+			var subStreams, subMessages int64
+			for _, s := range st.Subscribers {
+				subStreams += s.Streams
+				subMessages += s.Messages
+			}
+			if subStreams != streams || subMessages != tt.delivered {
+				t.Errorf("у подписчиков %d потоков и %d сообщений", subStreams, subMessages)
+			}
+			if tt.delivered > 0 && (st.MessagesPerSec <= 0 || st.Subscribers[0].MessagesPerSec <= 0) {
+				t.Errorf("сообщений в секунду: %g, у подписчика %g", st.MessagesPerSec, st.Subscribers[0].MessagesPerSec)
+			}
+
+			// Время первого сообщения — по потоку, интервалы и джиттер — по
+			// парам и тройкам соседних сообщений
+			want := map[string]int64{MetricFirstMessage: streams, MetricInterArrival: streams * (messages - 1), MetricJitter: streams * (messages - 2)}
+			for name, n := range want {
+				if tt.delivered == 0 {
+					n = 0
+				}
+				if got := res.Metrics[name].Count; got != n {
+					t.Errorf("%s: %d значений, ожидается %d", name, got, n)
+				}
+			}
+		})
+	}
+}
+
+func TestPushStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream PushStream
+		valid  bool
+		params *pb.StreamParams
+	}{
+		{"по умолчанию сервера", PushStream{}, true, nil},
+		{"fixed по умолчанию", PushStream{Count: 10, Interval: 2 * time.Millisecond, PayloadSize: 64}, true,
+			&pb.StreamParams{Count: 10, IntervalUs: 2000, PayloadSize: 64, Distribution: pb.IntervalDistribution_INTERVAL_FIXED}},
+		{"exponential", PushStream{Count: 1, Distribution: "exponential"}, true,
+			&pb.StreamParams{Count: 1, Distribution: pb.IntervalDistribution_INTERVAL_EXPONENTIAL}},
+		{"отрицательный интервал", PushStream{Count: 1, Interval: -time.Millisecond}, false, nil},
+		{"отрицательное число сообщений", PushStream{Count: -1}, false, nil},
+		{"неизвестное распределение", PushStream{Count: 1, Distribution: "poisson"}, false, nil},
+		{"payload больше максимального", PushStream{Count: 1, PayloadSize: payload.MaxSize + 1}, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.stream.validate(); (err == nil) != tt.valid {
+				t.Fatalf("validate = %v", err)
+			}
+			if !tt.valid {
+				return
+			}
+			if got := tt.stream.params(); !proto.Equal(got, tt.params) {
+				t.Errorf("params = %v, ожидается %v", got, tt.params)
+			}
+		})
+	}
+	if s := (PushStream{Count: 5, Interval: time.Millisecond}).String(); s != "count=5,interval=1ms,distribution=fixed,payload=0" {
+		t.Errorf("String = %q", s)
+	}
+}
@@ -0,0 +1,263 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Форматы вывода результатов
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Formats — все поддерживаемые форматы вывода
+var Formats = []string{FormatText, FormatJSON, FormatCSV}
+
+// Report — результаты всех бенчмарков одного запуска клиента
+type Report struct {
+	Target    string      `json:"target,omitempty"`
+	StartedAt time.Time   `json:"started_at"`
+	Results   []*Result   `json:"results"`
+	SLO       []SLOResult `json:"slo,omitempty"` // проверки SLO, если они заданы
+}
+
+// WriteReport выводит отчёт в заданном формате
+func WriteReport(w io.Writer, format string, report *Report) error {
+	switch format {
+	case FormatText:
+		return WriteText(w, report)
+	case FormatJSON:
+		return WriteJSON(w, report)
+	case FormatCSV:
+		return WriteCSV(w, report)
+	}
+	return fmt.Errorf("неизвестный формат вывода %q", format)
+}
+
+// WriteJSON выводит отчёт в JSON вместе с гистограммами
+func WriteJSON(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// WriteCSV выводит по одной строке на результат. Задержки — в миллисекундах.
+func WriteCSV(w io.Writer, report *Report) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"name", "method", "stage", "target", "run", "scenario", "concurrency", "request_size", "response_size", "payload_fill", "compression", "requests", "success", "failures", "errors", "discarded",
+		"started_at", "elapsed_s", "measured_s", "rps", "intended_rps",
+		"min_ms", "mean_ms", "stddev_ms", "max_ms", "cpu_s",
+		"sent_wire_bytes", "sent_bytes", "received_wire_bytes", "received_bytes",
+	}
+	for _, p := range ReportPercentiles {
+		header = append(header, "p"+strconv.FormatFloat(p, 'f', -1, 64)+"_ms")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range report.Results {
+		row := []string{
+			r.Name, r.Method, r.Stage, r.Target, strconv.Itoa(r.Run), string(r.Params.Scenario), strconv.Itoa(r.Params.Concurrency),
+			r.Params.RequestSize, r.Params.ResponseSize, r.Params.PayloadFill, r.Params.Compression, strconv.FormatInt(r.Requests, 10), strconv.FormatInt(r.Success, 10), strconv.FormatInt(r.Failures, 10),
+			formatErrors(r.Errors, ";"), strconv.FormatInt(r.Discarded, 10),
+			r.StartedAt.Format(time.RFC3339Nano), formatFloat(r.Elapsed.Seconds()), formatFloat(r.Measured.Seconds()),
+			formatFloat(r.RPS), formatFloat(r.IntendedRPS),
+			formatMs(r.Latency.Min), formatMs(r.Latency.Mean), formatMs(r.Latency.StdDev), formatMs(r.Latency.Max),
+			formatFloat(r.CPUTime.Seconds()),
+		}
+		var t Traffic
+		if r.Traffic != nil {
+			t = *r.Traffic
+		}
+		for _, v := range []int64{t.SentWire, t.SentRaw, t.ReceivedWire, t.ReceivedRaw} {
+			row = append(row, strconv.FormatInt(v, 10))
+		}
+		for _, p := range ReportPercentiles {
+			v, _ := r.Latency.Percentile(p)
+			row = append(row, formatMs(v))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteText выводит сводку результатов для человека
+func WriteText(w io.Writer, report *Report) error {
+	for _, r := range report.Results {
+		title := r.Name
+		if r.Method != "" {
+			title += " (" + r.Method + ")"
+		}
+		if r.Params.Mix != "" {
+			title += ", смесь " + r.Params.Mix
+		}
+		if r.Params.Compression != "" {
+			title += ", сжатие " + r.Params.Compression
+		}
+		if r.Params.PayloadFill != "" {
+			title += ", payload " + r.Params.PayloadFill
+		}
+		if r.Stage != "" {
+			title += ", этап " + r.Stage
+		}
+		if r.Target != "" {
+			title += ", цель " + r.Target
+		}
+		if r.Run > 0 {
+			title += fmt.Sprintf(", повтор %d", r.Run)
+		}
+		fmt.Fprintf(w, "=== %s ===\n", title)
+		fmt.Fprintf(w, "Всего запросов: %d, успешных: %d, неуспешных: %d\n", r.Requests, r.Success, r.Failures)
+		if r.Agents > 0 {
+			fmt.Fprintf(w, "Агентов: %d\n", r.Agents)
+		}
+		if len(r.Errors) > 0 {
+			fmt.Fprintf(w, "Ошибки: %s\n", formatErrors(r.Errors, ", "))
+		}
+		for _, c := range r.ErrorClasses {
+			phase := string(c.Phase)
+			if phase == "" {
+				phase = "?"
+			}
+			fmt.Fprintf(w, "  %s/%s: %d, например: %s\n", c.Code, phase, c.Count, strings.Join(c.Samples, " | "))
+		}
+		if r.Params.Timeout > 0 {
+			fmt.Fprintf(w, "Дедлайн %s: превышен у %d вызовов (%.2f%%)", r.Params.Timeout, r.DeadlineExceeded, deadlineRate(r))
+			if p99, ok := r.Latency.Percentile(99); ok && r.Success > 0 {
+				fmt.Fprintf(w, ", p99 задержки — %.0f%% дедлайна", float64(p99)/float64(r.Params.Timeout)*100)
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Общее время выполнения: %s\n", r.Elapsed)
+		if r.Params.WarmUp > 0 || r.Params.CoolDown > 0 {
+			fmt.Fprintf(w, "Измеряемая фаза: %s, отброшено запросов разогрева и остывания: %d\n", r.Measured, r.Discarded)
+		}
+		if r.Params.Scenario == ScenarioOpenLoop {
+			fmt.Fprintf(w, "Целевая частота (RPS): %.2f, фактическая: %.2f\n", r.IntendedRPS, r.RPS)
+		} else {
+			fmt.Fprintf(w, "Средняя скорость (RPS): %.2f\n", r.RPS)
+		}
+
+		fmt.Fprintf(w, "Latency %s\n", formatPercentiles(r.Latency))
+		fmt.Fprintf(w, "Latency mean: %s, stddev: %s\n", r.Latency.Mean, r.Latency.StdDev)
+		if len(r.SlowestTraces) > 0 {
+			fmt.Fprintf(w, "Самые медленные трассы: %s\n", formatTraces(r.SlowestTraces, slowestInText))
+		}
+		if len(r.Metrics) > 0 {
+			names := make([]string, 0, len(r.Metrics))
+			for name := range r.Metrics {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				m := r.Metrics[name]
+				fmt.Fprintf(w, "Метрика %s (%d): %s, mean: %s\n", name, m.Count, formatPercentiles(m), m.Mean)
+			}
+		}
+		if s := r.Streams; s != nil {
+			fmt.Fprintf(w, "Сообщения потоков: %d (%.2f/с), потоков завершено: %d, оборвано: %d, из них после первого сообщения: %d\n",
+				s.Messages, s.MessagesPerSec, s.Completed, s.Failed, s.Broken)
+			if len(s.Subscribers) > 0 {
+				rates := make([]float64, len(s.Subscribers))
+				for i, sub := range s.Subscribers {
+					rates[i] = sub.MessagesPerSec
+				}
+				sort.Float64s(rates)
+				fmt.Fprintf(w, "Сообщений/с на подписчика (%d): min: %.2f, median: %.2f, max: %.2f\n",
+					len(rates), rates[0], median(rates), rates[len(rates)-1])
+			}
+		}
+		if t := r.Traffic; t != nil {
+			fmt.Fprintf(w, "Трафик: отправлено %s (до сжатия %s), получено %s (до сжатия %s)\n",
+				humanBytes(float64(t.SentWire)), humanBytes(float64(t.SentRaw)),
+				humanBytes(float64(t.ReceivedWire)), humanBytes(float64(t.ReceivedRaw)))
+		}
+		if r.CPUTime > 0 {
+			fmt.Fprintf(w, "Процессорное время клиента: %s", r.CPUTime.Round(time.Millisecond))
+			if calls := r.Requests + r.Discarded; calls > 0 {
+				fmt.Fprintf(w, ", %s на вызов", r.CPUTime/time.Duration(calls))
+			}
+			fmt.Fprintln(w)
+		}
+		if len(r.Timeline) > 0 {
+			fmt.Fprintf(w, "По интервалам %s:\n", r.Params.Interval)
+			for _, s := range r.Timeline {
+				fmt.Fprintf(w, "  %s\n", formatSnapshot(s))
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	if err := writeSweepCurves(w, report.Results); err != nil {
+		return err
+	}
+	if err := writeCompressionTables(w, report.Results); err != nil {
+		return err
+	}
+	if len(report.SLO) > 0 {
+		return WriteSLOTable(w, report.SLO)
+	}
+	return nil
+}
+
+// formatPercentiles выводит перцентили и максимум: "p50: 1ms, ..., max: 9ms"
+func formatPercentiles(l LatencySummary) string {
+	parts := make([]string, 0, len(l.Percentiles)+1)
+	for _, p := range l.Percentiles {
+		parts = append(parts, fmt.Sprintf("p%s: %s", strconv.FormatFloat(p.P, 'f', -1, 64), p.Value))
+	}
+	parts = append(parts, fmt.Sprintf("max: %s", l.Max))
+	return strings.Join(parts, ", ")
+}
+
+// slowestInText — сколько самых медленных трасс показывает текстовый отчёт;
+// полный список — в JSON
+const slowestInText = 3
+
+// formatTraces выводит задержки и trace ID первых n вызовов: "1.2s 4bf92f35..., ..."
+func formatTraces(calls []TracedCall, n int) string {
+	parts := make([]string, 0, n)
+	for i, c := range calls {
+		if i == n {
+			break
+		}
+		parts = append(parts, c.Latency.String()+" "+c.TraceID)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatErrors выводит ошибки по кодам в стабильном порядке: "Unavailable=3;Internal=1"
+func formatErrors(errs map[string]int64, sep string) string {
+	codes := make([]string, 0, len(errs))
+	for code := range errs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = fmt.Sprintf("%s=%d", code, errs[code])
+	}
+	return strings.Join(parts, sep)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
+
+func formatMs(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
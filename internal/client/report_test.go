@@ -0,0 +1,118 @@
+package client
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sampleReport — отчёт с одним результатом и гистограммой задержек 1..100ms
+func sampleReport() *Report {
+	h := NewHistogram()
+	for i := 1; i <= 100; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &Report{
+		Target:    "localhost:50051",
+		StartedAt: started,
+		Results: []*Result{{
+			Name:      "UnaryPing",
+			Method:    "/benchmark.BenchmarkService/Ping",
+			Stage:     "burst",
+			Params:    RunParams{Scenario: ScenarioConstant, Concurrency: 4, Requests: 100},
+			StartedAt: started,
+			Requests:  103,
+			Success:   100,
+			Failures:  3,
+			Errors:    map[string]int64{"Unavailable": 2, "Internal": 1},
+			Elapsed:   2 * time.Second,
+			Measured:  time.Second,
+			RPS:       100,
+			Latency:   Summarize(h),
+			Histogram: h,
+		}},
+	}
+}
+
+func TestWriteJSONRoundTrip(t *testing.T) {
+	report := sampleReport()
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, FormatJSON, report); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, r := report.Results[0], got.Results[0]
+	if r.Name != want.Name || r.Stage != want.Stage || r.Params != want.Params || !reflect.DeepEqual(r.Errors, want.Errors) ||
+		r.Elapsed != want.Elapsed || !reflect.DeepEqual(r.Latency, want.Latency) || !got.StartedAt.Equal(report.StartedAt) {
+		t.Errorf("результат после JSON:\n%+v\nожидается\n%+v", r, want)
+	}
+	if r.Histogram == nil || r.Histogram.Count() != 100 || r.Histogram.Percentile(99) != want.Histogram.Percentile(99) {
+		t.Error("гистограмма не сохранилась в JSON")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, FormatCSV, sampleReport()); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || len(rows[0]) != len(rows[1]) {
+		t.Fatalf("строк %d, полей в заголовке и строке: %v", len(rows), rows)
+	}
+	row := map[string]string{}
+	for i, name := range rows[0] {
+		row[name] = rows[1][i]
+	}
+	want := map[string]string{
+		"name":        "UnaryPing",
+		"stage":       "burst",
+		"scenario":    "constant",
+		"concurrency": "4",
+		"failures":    "3",
+		"errors":      "Internal=1;Unavailable=2",
+		"elapsed_s":   "2.000",
+		"rps":         "100.000",
+		"max_ms":      "100.000",
+		"p50_ms":      "50.015", // гистограмма хранит значения с точностью 0.1%
+		"p99.9_ms":    "100.000",
+		"started_at":  "2026-01-02T03:04:05Z",
+	}
+	for name, v := range want {
+		if row[name] != v {
+			t.Errorf("%s = %q, ожидается %q", name, row[name], v)
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, FormatText, sampleReport()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"UnaryPing (/benchmark.BenchmarkService/Ping), этап burst", "Ошибки: Internal=1, Unavailable=2", "(RPS): 100.00", "max: 100ms"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("в отчёте нет %q:\n%s", want, out)
+		}
+	}
+	if err := WriteReport(&buf, "xml", sampleReport()); err == nil {
+		t.Error("неизвестный формат принят")
+	}
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// ReportPercentiles — перцентили, которые попадают в сводку результата
+var ReportPercentiles = []float64{50, 75, 90, 95, 99, 99.9, 99.99}
+
+// Result — итог бенчмарка одного RPC в машиночитаемом виде.
+// Длительности сериализуются в наносекундах (поля с суффиксом _ns).
+type Result struct {
+	Name             string                    `json:"name"`
+	Method           string                    `json:"method"`
+	Params           RunParams                 `json:"params"`
+	Stage            string                    `json:"stage,omitempty"`  // имя этапа плана
+	Target           string                    `json:"target,omitempty"` // имя цели, если их в плане несколько
+	Run              int                       `json:"run,omitempty"`    // номер повтора при -count > 1
+	StartedAt        time.Time                 `json:"started_at"`
+	FinishedAt       time.Time                 `json:"finished_at"`
+	Requests         int64                     `json:"requests"`
+	Success          int64                     `json:"success"`
+	Failures         int64                     `json:"failures"`
+	Errors           map[string]int64          `json:"errors,omitempty"`            // количество ошибок по gRPC коду
+	ErrorClasses     []ErrorClass              `json:"error_classes,omitempty"`     // ошибки по коду и этапу вызова с примерами
+	DeadlineExceeded int64                     `json:"deadline_exceeded,omitempty"` // вызовов, не уложившихся в Params.Timeout
+	Discarded        int64                     `json:"discarded"`                   // запросов разогрева и остывания
+	Elapsed          time.Duration             `json:"elapsed_ns"`
+	Measured         time.Duration             `json:"measured_ns"`
+	RPS              float64                   `json:"rps"`
+	IntendedRPS      float64                   `json:"intended_rps,omitempty"`
+	Latency          LatencySummary            `json:"latency"`
+	Histogram        *Histogram                `json:"histogram"`
+	Timeline         []Snapshot                `json:"timeline,omitempty"`       // снимки по интервалам Params.Interval
+	Metrics          map[string]LatencySummary `json:"metrics,omitempty"`        // дополнительные метрики нагрузки
+	Streams          *StreamStats              `json:"streams,omitempty"`        // сообщения серверных потоков
+	Traffic          *Traffic                  `json:"traffic,omitempty"`        // байты сообщений, если подключён StatsHandler
+	CPUTime          time.Duration             `json:"cpu_time_ns,omitempty"`    // процессорное время клиента за прогон
+	Agents           int                       `json:"agents,omitempty"`         // агентов распределённого прогона
+	SlowestTraces    []TracedCall              `json:"slowest_traces,omitempty"` // самые медленные вызовы из выборки трассировки
+
+	metricHists map[string]*Histogram // гистограммы Metrics, чтобы объединять результаты агентов
+}
+
+// StreamStats — сообщения и завершение серверных потоков. Считаются за весь
+// прогон, включая разогрев и остывание; частота — по Result.Elapsed.
+type StreamStats struct {
+	Messages       int64             `json:"messages"`
+	MessagesPerSec float64           `json:"messages_per_sec"`
+	Completed      int64             `json:"completed"` // потоков прочитано до конца
+	Failed         int64             `json:"failed"`    // потоков оборвано ошибкой
+	Broken         int64             `json:"broken"`    // из них после получения хотя бы одного сообщения
+	Subscribers    []SubscriberStats `json:"subscribers"`
+}
+
+// SubscriberStats — сообщения одного подписчика (воркера)
+type SubscriberStats struct {
+	Subscriber     int     `json:"subscriber"`
+	Streams        int64   `json:"streams"`
+	Messages       int64   `json:"messages"`
+	MessagesPerSec float64 `json:"messages_per_sec"`
+}
+
+// RunParams — параметры нагрузки, с которыми получен результат
+type RunParams struct {
+	Scenario          LoadScenario  `json:"scenario"`
+	Concurrency       int           `json:"concurrency"`
+	Requests          int           `json:"requests,omitempty"`
+	Duration          time.Duration `json:"duration_ns,omitempty"`
+	WarmUp            time.Duration `json:"warmup_ns,omitempty"`
+	CoolDown          time.Duration `json:"cooldown_ns,omitempty"`
+	TargetRPS         float64       `json:"target_rps,omitempty"`
+	Profile           string        `json:"profile,omitempty"`
+	ProfileTarget     ProfileTarget `json:"profile_target,omitempty"`
+	Timeout           time.Duration `json:"timeout_ns,omitempty"`
+	Interval          time.Duration `json:"interval_ns,omitempty"`
+	RequestSize       string        `json:"request_size,omitempty"`       // размер payload запроса, см. ParseSize
+	ResponseSize      string        `json:"response_size,omitempty"`      // размер payload ответа
+	Sweep             string        `json:"sweep,omitempty"`              // результат серии по размерам: request, response или both
+	PayloadFill       string        `json:"payload_fill,omitempty"`       // содержимое payload: random или text
+	Compression       string        `json:"compression,omitempty"`        // алгоритм сжатия сообщений
+	CompressionSeries bool          `json:"compression_series,omitempty"` // результат сравнения алгоритмов сжатия
+	Mix               string        `json:"mix,omitempty"`                // веса RPC смешанной нагрузки
+	StreamWindow      int           `json:"stream_window,omitempty"`
+	BatchMessages     int           `json:"batch_messages,omitempty"`
+	BatchGap          time.Duration `json:"batch_gap_ns,omitempty"`
+	Push              *PushStream   `json:"push,omitempty"` // параметры потока PushNotifications
+}
+
+// LatencySummary — сводка распределения задержек
+type LatencySummary struct {
+	Count       int64             `json:"count"`
+	Min         time.Duration     `json:"min_ns"`
+	Mean        time.Duration     `json:"mean_ns"`
+	StdDev      time.Duration     `json:"stddev_ns"`
+	Max         time.Duration     `json:"max_ns"`
+	Percentiles []PercentileValue `json:"percentiles"`
+}
+
+// PercentileValue — значение одного перцентиля
+type PercentileValue struct {
+	P     float64       `json:"p"`
+	Value time.Duration `json:"value_ns"`
+}
+
+// Summarize строит сводку по гистограмме
+func Summarize(h *Histogram) LatencySummary {
+	s := LatencySummary{
+		Count:  h.Count(),
+		Min:    h.Min(),
+		Mean:   h.Mean(),
+		StdDev: h.StdDev(),
+		Max:    h.Max(),
+	}
+	for _, p := range ReportPercentiles {
+		s.Percentiles = append(s.Percentiles, PercentileValue{P: p, Value: h.Percentile(p)})
+	}
+	return s
+}
+
+// Percentile возвращает значение перцентиля p из сводки, ok=false — если его нет
+func (s LatencySummary) Percentile(p float64) (time.Duration, bool) {
+	for _, v := range s.Percentiles {
+		if v.P == p {
+			return v.Value, true
+		}
+	}
+	return 0, false
+}
+
+func newRunParams(opts LoadOptions) RunParams {
+	params := RunParams{
+		Scenario:          opts.Scenario,
+		Concurrency:       opts.Concurrency,
+		Duration:          opts.Duration,
+		WarmUp:            opts.WarmUp,
+		CoolDown:          opts.CoolDown,
+		TargetRPS:         opts.TargetRPS,
+		Timeout:           opts.Timeout,
+		Interval:          opts.Interval,
+		RequestSize:       opts.Payload.Size.String(),
+		ResponseSize:      opts.Payload.ResponseSize.String(),
+		Sweep:             opts.Sweep,
+		PayloadFill:       opts.Payload.Fill,
+		Compression:       opts.Compression,
+		CompressionSeries: opts.CompressionSeries,
+		StreamWindow:      opts.StreamWindow,
+	}
+	if opts.Duration <= 0 {
+		params.Requests = opts.Requests
+	}
+	if opts.Batch.Messages > 1 {
+		// одно сообщение в потоке — режим по умолчанию, в ключ сравнения не входит
+		params.BatchMessages, params.BatchGap = opts.Batch.Messages, opts.Batch.Gap
+	}
+	if opts.Push.Count > 0 {
+		push := opts.Push
+		params.Push = &push
+	}
+	if opts.Profile != nil {
+		params.Profile = fmt.Sprint(opts.Profile)
+		params.ProfileTarget = opts.profileTarget()
+	}
+	return params
+}
+
+// newResult собирает результат из статистики воркеров и итогов runLoad
+func newResult(name, method string, opts LoadOptions, started time.Time, run loadRun, stats *workerStatsSet) *Result {
+	success, fail, errs, classes, latency := stats.merge()
+	res := &Result{
+		Name:             name,
+		Method:           method,
+		Params:           newRunParams(opts),
+		StartedAt:        started,
+		FinishedAt:       started.Add(run.elapsed),
+		Requests:         run.requests,
+		Success:          success,
+		Failures:         fail,
+		Errors:           errs,
+		ErrorClasses:     classes,
+		DeadlineExceeded: errs[codes.DeadlineExceeded.String()],
+		Discarded:        run.discarded,
+		Elapsed:          run.elapsed,
+		Measured:         run.measured,
+		IntendedRPS:      run.intendedRPS,
+		Latency:          Summarize(latency),
+		Histogram:        latency,
+		Timeline:         stats.snapshots(),
+		SlowestTraces:    stats.slowest(),
+	}
+	if run.measured > 0 {
+		res.RPS = float64(success) / run.measured.Seconds()
+	}
+	return res
+}
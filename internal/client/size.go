@@ -0,0 +1,208 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Size — размер сообщения в байтах: фиксированный ("1KiB"), равномерный
+// в диапазоне ("uniform:64-4KiB") или дискретное распределение с весами
+// ("dist:64=70,1KiB=25,1MiB=5"). Нулевое значение — 0 байт.
+type Size struct {
+	kind    string // "" — фиксированный, "uniform", "dist"
+	values  []int  // фиксированный: одно значение; uniform: min и max; dist: размеры
+	weights []float64
+	spec    string // нормализованная запись, см. String
+}
+
+// ParseSize разбирает размер. Суффиксы: B, K/KB/KiB (1024), M/MB/MiB (1024²).
+func ParseSize(spec string) (Size, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "0" {
+		return Size{}, nil
+	}
+	kind, rest, ok := strings.Cut(spec, ":")
+	if !ok {
+		n, err := parseBytes(spec)
+		if err != nil {
+			return Size{}, err
+		}
+		return FixedSize(n), nil
+	}
+	s := Size{kind: kind}
+	switch kind {
+	case "uniform":
+		lo, hi, ok := strings.Cut(rest, "-")
+		if !ok {
+			return Size{}, fmt.Errorf("размер %q: ожидается uniform:min-max", spec)
+		}
+		min, err := parseBytes(lo)
+		if err != nil {
+			return Size{}, err
+		}
+		max, err := parseBytes(hi)
+		if err != nil {
+			return Size{}, err
+		}
+		if min > max {
+			return Size{}, fmt.Errorf("размер %q: min больше max", spec)
+		}
+		s.values = []int{min, max}
+		s.spec = "uniform:" + FormatBytes(min) + "-" + FormatBytes(max)
+	case "dist":
+		var total float64
+		var parts []string
+		for _, kv := range strings.Split(rest, ",") {
+			v, w, ok := strings.Cut(strings.TrimSpace(kv), "=")
+			if !ok {
+				return Size{}, fmt.Errorf("размер %q: ожидается размер=вес, получено %q", spec, kv)
+			}
+			n, err := parseBytes(v)
+			if err != nil {
+				return Size{}, err
+			}
+			weight, err := strconv.ParseFloat(w, 64)
+			if err != nil || weight <= 0 {
+				return Size{}, fmt.Errorf("размер %q: вес %q должен быть положительным числом", spec, w)
+			}
+			total += weight
+			s.values = append(s.values, n)
+			s.weights = append(s.weights, total)
+			parts = append(parts, FormatBytes(n)+"="+strconv.FormatFloat(weight, 'f', -1, 64))
+		}
+		s.spec = "dist:" + strings.Join(parts, ",")
+	default:
+		return Size{}, fmt.Errorf("размер %q: неизвестное распределение %q, доступны uniform и dist", spec, kind)
+	}
+	return s, nil
+}
+
+// FixedSize — фиксированный размер n байт
+func FixedSize(n int) Size {
+	if n <= 0 {
+		return Size{}
+	}
+	return Size{values: []int{n}, spec: FormatBytes(n)}
+}
+
+// parseBytes разбирает число байт с необязательным суффиксом
+func parseBytes(v string) (int, error) {
+	v = strings.TrimSpace(v)
+	num := strings.TrimRightFunc(v, func(r rune) bool { return r < '0' || r > '9' })
+	mult := 1
+	switch strings.ToUpper(v[len(num):]) {
+	case "", "B":
+	case "K", "KB", "KIB":
+		mult = 1 << 10
+	case "M", "MB", "MIB":
+		mult = 1 << 20
+	default:
+		return 0, fmt.Errorf("размер %q: неизвестная единица, доступны B, KiB, MiB", v)
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("размер %q: ожидается неотрицательное число байт", v)
+	}
+	if n > math.MaxInt/mult {
+		return 0, fmt.Errorf("размер %q слишком большой", v)
+	}
+	return n * mult, nil
+}
+
+// FormatBytes выводит размер в самой крупной единице, в которой он целый: "64B", "4KiB"
+func FormatBytes(n int) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return strconv.Itoa(n>>20) + "MiB"
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return strconv.Itoa(n>>10) + "KiB"
+	}
+	return strconv.Itoa(n) + "B"
+}
+
+// IsZero сообщает, что размер не задан
+func (s Size) IsZero() bool { return len(s.values) == 0 }
+
+// Max — наибольший возможный размер
+func (s Size) Max() int {
+	max := 0
+	for _, v := range s.values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// Mean — средний размер
+func (s Size) Mean() float64 {
+	switch s.kind {
+	case "":
+		return float64(s.Max())
+	case "uniform":
+		return float64(s.values[0]+s.values[1]) / 2
+	}
+	var sum, prev float64
+	for i, v := range s.values {
+		sum += float64(v) * (s.weights[i] - prev)
+		prev = s.weights[i]
+	}
+	return sum / prev
+}
+
+// next выбирает размер очередного сообщения
+func (s Size) next() int {
+	switch {
+	case s.IsZero():
+		return 0
+	case s.kind == "":
+		return s.values[0]
+	case s.kind == "uniform":
+		return s.values[0] + rand.Intn(s.values[1]-s.values[0]+1)
+	}
+	x := rand.Float64() * s.weights[len(s.weights)-1]
+	return s.values[sort.SearchFloat64s(s.weights, x)]
+}
+
+// String возвращает размер в виде, из которого он разбирается
+func (s Size) String() string { return s.spec }
+
+func (s Size) MarshalJSON() ([]byte, error) { return json.Marshal(s.spec) }
+
+// UnmarshalJSON принимает строку размера или число байт
+func (s *Size) UnmarshalJSON(data []byte) error {
+	var spec string
+	if err := json.Unmarshal(data, &spec); err != nil {
+		var n int
+		if json.Unmarshal(data, &n) != nil {
+			return err
+		}
+		spec = strconv.Itoa(n)
+	}
+	v, err := ParseSize(spec)
+	*s = v
+	return err
+}
+
+// UnmarshalYAML принимает строку размера или число байт
+func (s *Size) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var spec string
+	if err := unmarshal(&spec); err != nil {
+		return err
+	}
+	v, err := ParseSize(spec)
+	*s = v
+	return err
+}
+
+// Set позволяет использовать *Size как значение флага
+func (s *Size) Set(spec string) error {
+	v, err := ParseSize(spec)
+	*s = v
+	return err
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string // нормализованная запись
+		max     int
+		mean    float64
+		wantErr string
+	}{
+		{spec: "", want: "", max: 0},
+		{spec: "0", want: "", max: 0},
+		{spec: "64", want: "64B", max: 64, mean: 64},
+		{spec: "1024b", want: "1KiB", max: 1024, mean: 1024},
+		{spec: "4k", want: "4KiB", max: 4 << 10, mean: 4 << 10},
+		{spec: "2MiB", want: "2MiB", max: 2 << 20, mean: 2 << 20},
+		{spec: "1500", want: "1500B", max: 1500, mean: 1500},
+		{spec: "uniform:64-1KiB", want: "uniform:64B-1KiB", max: 1024, mean: 544},
+		{spec: "dist:64=3,1KiB=1", want: "dist:64B=3,1KiB=1", max: 1024, mean: 304},
+		{spec: "1GiB", wantErr: "неизвестная единица"},
+		{spec: "-5", wantErr: "неотрицательное"},
+		{spec: "uniform:1KiB-64", wantErr: "min больше max"},
+		{spec: "uniform:64", wantErr: "uniform:min-max"},
+		{spec: "dist:64=0", wantErr: "положительным"},
+		{spec: "normal:64", wantErr: "неизвестное распределение"},
+		{spec: "9223372036854775807M", wantErr: "слишком большой"},
+		{spec: "8796093022208MiB", wantErr: "слишком большой"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSize(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидается %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.String() != tt.want || s.Max() != tt.max || s.Mean() != tt.mean {
+				t.Errorf("%q: max %d, mean %g; ожидается %q: %d, %g", s, s.Max(), s.Mean(), tt.want, tt.max, tt.mean)
+			}
+			for i := 0; i < 100; i++ {
+				if n := s.next(); n < 0 || n > tt.max {
+					t.Fatalf("размер %d вне [0, %d]", n, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int]string{0: "0B", 1023: "1023B", 1024: "1KiB", 1536: "1536B", 3 << 20: "3MiB", 1<<20 + 1024: "1025KiB"} {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %s, ожидается %s", n, got, want)
+		}
+	}
+}
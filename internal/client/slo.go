@@ -0,0 +1,258 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// SLO — целевой уровень одной метрики результата. Задаётся строкой вида
+// "[метод:]метрика<оператор><значение>", например:
+//
+//	/benchmark.BenchmarkService/Ping:p99<20ms
+//	Ping:mean<=5ms
+//	error_rate<0.5%
+//	rps>=5000
+//
+// Метод — полное имя gRPC метода, имя бенчмарка или короткое имя RPC;
+// без него SLO проверяется для всех результатов. Метрики: pN (перцентиль),
+// mean, max, error_rate и deadline_rate (в процентах) и rps.
+type SLO struct {
+	Method    string  `json:"method,omitempty"`
+	Metric    string  `json:"metric"`
+	Op        string  `json:"op"`
+	Threshold float64 `json:"threshold"` // задержки — в миллисекундах
+}
+
+// sloOps — операторы сравнения, длинные раньше коротких
+var sloOps = []string{"<=", ">=", "<", ">"}
+
+// ParseSLOList разбирает SLO, перечисленные через запятую. Метод перед
+// SLO относится и к следующим SLO списка, пока не указан другой:
+// "Ping:p99<20ms,error_rate<0.5%" задаёт оба SLO для Ping. SLO для всех
+// результатов перечисляются до первого метода или отдельным списком.
+func ParseSLOList(spec string) ([]SLO, error) {
+	var slos []SLO
+	method := ""
+	for _, part := range strings.Split(spec, ",") {
+		slo, err := ParseSLO(part)
+		if err != nil {
+			return nil, err
+		}
+		if slo.Method == "" {
+			slo.Method = method
+		}
+		method = slo.Method
+		slos = append(slos, slo)
+	}
+	return slos, nil
+}
+
+// ParseSLO разбирает SLO из строки конфигурации
+func ParseSLO(spec string) (SLO, error) {
+	var slo SLO
+	expr := strings.TrimSpace(spec)
+	if method, rest, ok := strings.Cut(expr, ":"); ok {
+		slo.Method, expr = strings.TrimSpace(method), rest
+	}
+
+	var metric, value string
+	for _, op := range sloOps {
+		if i := strings.Index(expr, op); i > 0 {
+			metric, slo.Op, value = strings.TrimSpace(expr[:i]), op, strings.TrimSpace(expr[i+len(op):])
+			break
+		}
+	}
+	if slo.Op == "" {
+		return slo, fmt.Errorf("SLO %q: ожидается метрика<значение, например p99<20ms", spec)
+	}
+	slo.Metric = metric
+
+	var err error
+	switch {
+	case isLatencyMetric(metric):
+		var d time.Duration
+		d, err = time.ParseDuration(value)
+		slo.Threshold = ms(d)
+	case metric == "error_rate" || metric == "deadline_rate":
+		slo.Threshold, err = strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	case metric == "rps":
+		slo.Threshold, err = strconv.ParseFloat(value, 64)
+	default:
+		return slo, fmt.Errorf("SLO %q: неизвестная метрика %q", spec, metric)
+	}
+	if err != nil {
+		return slo, fmt.Errorf("SLO %q: %v", spec, err)
+	}
+	return slo, nil
+}
+
+// isRateMetric сообщает, что метрика — доля вызовов в процентах
+func isRateMetric(metric string) bool {
+	return metric == "error_rate" || metric == "deadline_rate"
+}
+
+func isLatencyMetric(metric string) bool {
+	if metric == "mean" || metric == "max" {
+		return true
+	}
+	if p, ok := strings.CutPrefix(metric, "p"); ok {
+		v, err := strconv.ParseFloat(p, 64)
+		return err == nil && v > 0 && v <= 100
+	}
+	return false
+}
+
+// String возвращает SLO в форме, из которой он разбирается
+func (s SLO) String() string {
+	value := strconv.FormatFloat(s.Threshold, 'f', -1, 64)
+	switch {
+	case isLatencyMetric(s.Metric):
+		value = time.Duration(s.Threshold * float64(time.Millisecond)).String()
+	case isRateMetric(s.Metric):
+		value += "%"
+	}
+	expr := s.Metric + s.Op + value
+	if s.Method != "" {
+		return s.Method + ":" + expr
+	}
+	return expr
+}
+
+// matches сообщает, относится ли SLO к результату r
+func (s SLO) matches(r *Result) bool {
+	if s.Method == "" || s.Method == r.Method || s.Method == r.Name {
+		return true
+	}
+	_, short, _ := strings.Cut(strings.TrimPrefix(r.Method, "/"), "/")
+	return strings.EqualFold(s.Method, short)
+}
+
+// value — значение метрики SLO в результате r
+func (s SLO) value(r *Result) (float64, error) {
+	switch s.Metric {
+	case "rps":
+		return r.RPS, nil
+	case "error_rate":
+		return errorRate(r), nil
+	case "deadline_rate":
+		return deadlineRate(r), nil
+	case "mean":
+		return ms(r.Latency.Mean), nil
+	case "max":
+		return ms(r.Latency.Max), nil
+	}
+	p, _ := strconv.ParseFloat(strings.TrimPrefix(s.Metric, "p"), 64)
+	if v, ok := r.Latency.Percentile(p); ok {
+		return ms(v), nil
+	}
+	if r.Histogram != nil {
+		return ms(r.Histogram.Percentile(p)), nil
+	}
+	return 0, fmt.Errorf("перцентиль %s отсутствует в результате", s.Metric)
+}
+
+func (s SLO) holds(v float64) bool {
+	switch s.Op {
+	case "<":
+		return v < s.Threshold
+	case "<=":
+		return v <= s.Threshold
+	case ">":
+		return v > s.Threshold
+	case ">=":
+		return v >= s.Threshold
+	}
+	return false
+}
+
+// SLOResult — итог проверки SLO на одном результате.
+// Name пуст, если SLO не подошёл ни к одному результату: это тоже нарушение.
+type SLOResult struct {
+	SLO    SLO     `json:"slo"`
+	Name   string  `json:"name,omitempty"`
+	Method string  `json:"method,omitempty"`
+	Stage  string  `json:"stage,omitempty"`
+	Run    int     `json:"run,omitempty"`
+	Actual float64 `json:"actual"`
+	Pass   bool    `json:"pass"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// CheckSLOs проверяет каждый SLO на всех подходящих результатах
+func CheckSLOs(slos []SLO, results []*Result) []SLOResult {
+	var checks []SLOResult
+	for _, slo := range slos {
+		matched := false
+		for _, r := range results {
+			if !slo.matches(r) {
+				continue
+			}
+			matched = true
+			check := SLOResult{SLO: slo, Name: r.Name, Method: r.Method, Stage: r.Stage, Run: r.Run}
+			v, err := slo.value(r)
+			if err != nil {
+				check.Error = err.Error()
+			} else {
+				check.Actual, check.Pass = v, slo.holds(v)
+			}
+			checks = append(checks, check)
+		}
+		if !matched {
+			checks = append(checks, SLOResult{SLO: slo, Error: "нет результатов для метода"})
+		}
+	}
+	return checks
+}
+
+// SLOsPassed сообщает, что все проверки пройдены
+func SLOsPassed(checks []SLOResult) bool {
+	for _, c := range checks {
+		if !c.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteSLOTable выводит таблицу проверок SLO
+func WriteSLOTable(w io.Writer, checks []SLOResult) error {
+	fmt.Fprintln(w, "=== SLO ===")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "цель\tбенчмарк\tфакт\tитог\t")
+	for _, c := range checks {
+		name, actual := c.Name, c.actualString()
+		if c.Stage != "" {
+			name += " [" + c.Stage + "]"
+		}
+		if c.Run > 0 {
+			name += " #" + strconv.Itoa(c.Run)
+		}
+		if c.Error != "" {
+			actual = c.Error
+		}
+		verdict := "OK"
+		if !c.Pass {
+			verdict = "НАРУШЕН"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", c.SLO, name, actual, verdict)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func (c SLOResult) actualString() string {
+	switch {
+	case isLatencyMetric(c.SLO.Metric):
+		return time.Duration(c.Actual * float64(time.Millisecond)).String()
+	case isRateMetric(c.SLO.Metric):
+		return strconv.FormatFloat(c.Actual, 'f', 3, 64) + "%"
+	}
+	return strconv.FormatFloat(c.Actual, 'f', 2, 64)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSLOList(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []SLO
+	}{
+		{"Ping:p99<20ms,error_rate<0.5%,rps>=5000", []SLO{
+			{Method: "Ping", Metric: "p99", Op: "<", Threshold: 20},
+			{Method: "Ping", Metric: "error_rate", Op: "<", Threshold: 0.5},
+			{Method: "Ping", Metric: "rps", Op: ">=", Threshold: 5000},
+		}},
+		{"error_rate<1%,Ping:p99<20ms,Stats:mean<=5ms,max<1s", []SLO{
+			{Metric: "error_rate", Op: "<", Threshold: 1},
+			{Method: "Ping", Metric: "p99", Op: "<", Threshold: 20},
+			{Method: "Stats", Metric: "mean", Op: "<=", Threshold: 5},
+			{Method: "Stats", Metric: "max", Op: "<", Threshold: 1000},
+		}},
+		{"/benchmark.BenchmarkService/Ping:p99.9<50ms, deadline_rate<0.1%", []SLO{
+			{Method: "/benchmark.BenchmarkService/Ping", Metric: "p99.9", Op: "<", Threshold: 50},
+			{Method: "/benchmark.BenchmarkService/Ping", Metric: "deadline_rate", Op: "<", Threshold: 0.1},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSLOList(tt.spec)
+		if err != nil {
+			t.Errorf("%s: %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n  получено  %+v\n  ожидается %+v", tt.spec, got, tt.want)
+		}
+	}
+
+	if _, err := ParseSLOList("Ping:p99<20ms,latency<1s"); err == nil {
+		t.Error("неизвестная метрика должна отклоняться")
+	}
+}
+
+func TestSLOResultActualString(t *testing.T) {
+	tests := []struct {
+		metric string
+		actual float64
+		want   string
+	}{
+		{"p99", 12.5, "12.5ms"},
+		{"max", 1500, "1.5s"},
+		{"error_rate", 0.25, "0.250%"},
+		{"deadline_rate", 0.25, "0.250%"},
+		{"rps", 5123.456, "5123.46"},
+	}
+	for _, tt := range tests {
+		c := SLOResult{SLO: SLO{Metric: tt.metric}, Actual: tt.actual}
+		if got := c.actualString(); got != tt.want {
+			t.Errorf("%s: %s, ожидается %s", tt.metric, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// confidenceZ — z-квантиль для 95% доверительных интервалов
+const confidenceZ = 1.96
+
+// bootstrapResamples — число повторных выборок при bootstrap-оценке интервала
+const bootstrapResamples = 2000
+
+// exactMannWhitneyLimit — до какого суммарного размера выборок без совпадений
+// p-value Манна-Уитни считается точно, а не нормальным приближением
+const exactMannWhitneyLimit = 50
+
+// MannWhitneyU — двусторонний U-критерий Манна-Уитни для двух выборок.
+// Возвращает статистику U для x и p-value.
+func MannWhitneyU(x, y []float64) (u, p float64) {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+
+	type sample struct {
+		v     float64
+		fromX bool
+	}
+	all := make([]sample, 0, n1+n2)
+	for _, v := range x {
+		all = append(all, sample{v, true})
+	}
+	for _, v := range y {
+		all = append(all, sample{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// Ранги с усреднением для совпадающих значений
+	var rankX, tieSum float64
+	ties := false
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromX {
+				rankX += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieSum += t*t*t - t
+		}
+		i = j
+	}
+	u = rankX - float64(n1*(n1+1))/2
+
+	if !ties && n1+n2 <= exactMannWhitneyLimit {
+		return u, exactMannWhitneyP(u, n1, n2)
+	}
+	return u, normalMannWhitneyP(u, float64(n1), float64(n2), tieSum)
+}
+
+// normalMannWhitneyP — p-value по нормальному приближению с поправкой на совпадения
+func normalMannWhitneyP(u, n1, n2, tieSum float64) float64 {
+	n := n1 + n2
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieSum/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// exactMannWhitneyP — точное двустороннее p-value: распределение U
+// считается подсчётом перестановок динамическим программированием
+func exactMannWhitneyP(u float64, n1, n2 int) float64 {
+	// counts[i][j][k] — число способов получить U = k для i и j элементов;
+	// хранится только текущий слой по i
+	maxU := n1 * n2
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = make([]float64, maxU+1)
+		prev[j][0] = 1
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		cur[0] = make([]float64, maxU+1)
+		cur[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			cur[j] = make([]float64, maxU+1)
+			for k := 0; k <= maxU; k++ {
+				// последний по величине элемент из x: он больше всех j элементов y
+				if k >= j {
+					cur[j][k] += prev[j][k-j]
+				}
+				// последний элемент из y
+				cur[j][k] += cur[j-1][k]
+			}
+		}
+		prev = cur
+	}
+	dist := prev[n2]
+
+	var total float64
+	for _, c := range dist {
+		total += c
+	}
+	mean := float64(maxU) / 2
+	dev := math.Abs(u - mean)
+	var tail float64
+	for k, c := range dist {
+		if math.Abs(float64(k)-mean) >= dev-1e-9 {
+			tail += c
+		}
+	}
+	return math.Min(1, tail/total)
+}
+
+// MannWhitneyHistograms — U-критерий Манна-Уитни по всем значениям двух
+// гистограмм. Значения из одного бакета считаются совпадающими, поэтому
+// p-value считается нормальным приближением за один проход по бакетам.
+func MannWhitneyHistograms(a, b *Histogram) (u, p float64) {
+	n1, n2 := float64(a.Count()), float64(b.Count())
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+	size := len(a.counts)
+	if len(b.counts) > size {
+		size = len(b.counts)
+	}
+	var belowB, tieSum float64
+	for i := 0; i < size; i++ {
+		var ca, cb float64
+		if i < len(a.counts) {
+			ca = float64(a.counts[i])
+		}
+		if i < len(b.counts) {
+			cb = float64(b.counts[i])
+		}
+		// каждое значение a больше всех значений b из меньших бакетов
+		// и делит половину пар с b из своего бакета
+		u += ca * (belowB + cb/2)
+		belowB += cb
+		if t := ca + cb; t > 1 {
+			tieSum += t*t*t - t
+		}
+	}
+	return u, normalMannWhitneyP(u, n1, n2, tieSum)
+}
+
+// median — медиана выборки
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+// bootstrapMedianCI — 95% bootstrap-интервал для медианы выборки.
+// Генератор инициализируется фиксированно, чтобы отчёт был воспроизводим.
+func bootstrapMedianCI(xs []float64) (lo, hi float64) {
+	if len(xs) < 2 {
+		m := median(xs)
+		return m, m
+	}
+	rng := rand.New(rand.NewSource(1))
+	medians := make([]float64, bootstrapResamples)
+	resample := make([]float64, len(xs))
+	for i := range medians {
+		for j := range resample {
+			resample[j] = xs[rng.Intn(len(xs))]
+		}
+		medians[i] = median(resample)
+	}
+	sort.Float64s(medians)
+	return medians[int(0.025*float64(bootstrapResamples))], medians[int(0.975*float64(bootstrapResamples))-1]
+}
+
+// percentileCI — 95% интервал для перцентиля p по порядковым статистикам:
+// ранг перцентиля имеет биномиальное распределение B(n, p/100)
+func percentileCI(h *Histogram, p float64) (lo, hi float64) {
+	n := float64(h.Count())
+	if n == 0 {
+		return 0, 0
+	}
+	q := p / 100
+	spread := confidenceZ * math.Sqrt(n*q*(1-q))
+	loRank := int64(math.Max(1, math.Floor(n*q-spread)))
+	hiRank := int64(math.Min(n, math.Ceil(n*q+spread)))
+	return ms(h.valueAtRank(loRank)), ms(h.valueAtRank(hiRank))
+}
+
+// meanCI — 95% интервал для среднего по нормальному приближению
+func meanCI(h *Histogram) (lo, hi float64) {
+	if h.Count() == 0 {
+		return 0, 0
+	}
+	spread := confidenceZ * ms(h.StdDev()) / math.Sqrt(float64(h.Count()))
+	return ms(h.Mean()) - spread, ms(h.Mean()) + spread
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitneyU(t *testing.T) {
+	tests := []struct {
+		name  string
+		x, y  []float64
+		wantU float64
+		wantP float64
+	}{
+		// полное разделение: p = 2/C(n1+n2, n1)
+		{"3 и 3 разделены", []float64{1, 2, 3}, []float64{4, 5, 6}, 0, 0.1},
+		{"4 и 4 разделены", []float64{1, 2, 3, 4}, []float64{5, 6, 7, 8}, 0, 2.0 / 70},
+		{"4 и 4 наоборот", []float64{5, 6, 7, 8}, []float64{1, 2, 3, 4}, 16, 2.0 / 70},
+		// таблица критических значений: для 5 и 5 при alpha=0.05 U ≤ 2
+		{"5 и 5, U=2", []float64{1, 2, 3, 4, 7}, []float64{5, 6, 8, 9, 10}, 2, 8.0 / 252},
+		{"5 и 5, U=3", []float64{1, 2, 3, 5, 7}, []float64{4, 6, 8, 9, 10}, 3, 14.0 / 252},
+		{"перемешаны", []float64{1, 4, 5, 8}, []float64{2, 3, 6, 7}, 8, 1},
+		// совпадения: нормальное приближение с поправкой
+		{"совпадения", []float64{1, 2, 2}, []float64{2, 3, 4}, 1, 0.16415972847851523},
+		{"пустая выборка", nil, []float64{1}, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, p := MannWhitneyU(tt.x, tt.y)
+			if u != tt.wantU || math.Abs(p-tt.wantP) > 1e-9 {
+				t.Errorf("U, p = %g, %g; ожидается %g, %g", u, p, tt.wantU, tt.wantP)
+			}
+		})
+	}
+}
+
+func TestExactMannWhitneyP(t *testing.T) {
+	for _, n := range [][2]int{{1, 1}, {2, 3}, {3, 3}, {4, 6}, {5, 5}, {6, 4}} {
+		n1, n2 := n[0], n[1]
+		ref := bruteForceUDistribution(n1, n2)
+		for u := 0; u <= n1*n2; u++ {
+			want := bruteForceP(ref, float64(u))
+			if got := exactMannWhitneyP(float64(u), n1, n2); math.Abs(got-want) > 1e-12 {
+				t.Errorf("n1=%d n2=%d U=%d: p = %g, ожидается %g", n1, n2, u, got, want)
+			}
+		}
+	}
+}
+
+// bruteForceUDistribution перебирает все C(n1+n2, n1) расстановки рангов
+// выборки x и возвращает число расстановок для каждого U
+func bruteForceUDistribution(n1, n2 int) []float64 {
+	dist := make([]float64, n1*n2+1)
+	n := n1 + n2
+	for mask := 0; mask < 1<<n; mask++ {
+		ones, u := 0, 0
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			ones++
+			// элемент x с рангом i больше всех элементов y с меньшими рангами
+			u += i - (ones - 1)
+		}
+		if ones == n1 {
+			dist[u]++
+		}
+	}
+	return dist
+}
+
+func bruteForceP(dist []float64, u float64) float64 {
+	var total, tail float64
+	mean := float64(len(dist)-1) / 2
+	for k, c := range dist {
+		total += c
+		if math.Abs(float64(k)-mean) >= math.Abs(u-mean) {
+			tail += c
+		}
+	}
+	return tail / total
+}
+
+func TestNormalMannWhitneyP(t *testing.T) {
+	tests := []struct {
+		name           string
+		u, n1, n2, tie float64
+		want           float64
+	}{
+		{"U в центре", 50, 10, 10, 0, 1},
+		{"U в пределах поправки", 50.4, 10, 10, 0, 1},
+		// z = (|20-50| - 0.5) / sqrt(175)
+		{"10 и 10, U=20", 20, 10, 10, 0, 0.025748080821108084},
+		{"симметрия", 80, 10, 10, 0, 0.025748080821108084},
+		{"совпадения", 1, 3, 3, 24, 0.16415972847851523},
+		{"все значения равны", 2, 2, 2, 60, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalMannWhitneyP(tt.u, tt.n1, tt.n2, tt.tie); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("p = %g, ожидается %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBootstrapMedianCI(t *testing.T) {
+	tests := []struct {
+		name   string
+		xs     []float64
+		lo, hi float64
+	}{
+		{"пусто", nil, 0, 0},
+		{"одно значение", []float64{7}, 7, 7},
+		{"одинаковые значения", []float64{3, 3, 3, 3}, 3, 3},
+		// медиана двух значений принимает 1, 1.5 или 2 с вероятностями 1/4, 1/2, 1/4
+		{"два значения", []float64{1, 2}, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if lo, hi := bootstrapMedianCI(tt.xs); lo != tt.lo || hi != tt.hi {
+				t.Errorf("интервал [%g, %g], ожидается [%g, %g]", lo, hi, tt.lo, tt.hi)
+			}
+		})
+	}
+
+	xs := []float64{9, 1, 8, 2, 7, 3, 6, 4, 5, 100}
+	lo, hi := bootstrapMedianCI(xs)
+	if m := median(xs); lo > m || m > hi {
+		t.Errorf("интервал [%g, %g] не содержит медиану %g", lo, hi, m)
+	}
+	// выброс не должен растягивать интервал медианы
+	if lo < 1 || hi > 9 {
+		t.Errorf("интервал [%g, %g] шире середины выборки", lo, hi)
+	}
+	if lo2, hi2 := bootstrapMedianCI(xs); lo2 != lo || hi2 != hi {
+		t.Errorf("интервал не воспроизводится: [%g, %g] и [%g, %g]", lo, hi, lo2, hi2)
+	}
+}
+
+func TestMinMannWhitneyP(t *testing.T) {
+	tests := []struct {
+		n1, n2 int
+		alpha  float64
+		want   float64
+		ok     bool
+	}{
+		{3, 3, 0.05, 0.1, false},
+		{4, 4, 0.05, 2.0 / 70, true},
+		{4, 4, 0.01, 2.0 / 70, false},
+		{5, 5, 0.01, 2.0 / 252, true},
+		{3, 10, 0.05, 2.0 / 286, false}, // меньше minRunsForTest
+	}
+	for _, tt := range tests {
+		if got := minMannWhitneyP(tt.n1, tt.n2); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("minMannWhitneyP(%d, %d) = %g, ожидается %g", tt.n1, tt.n2, got, tt.want)
+		}
+		if got := perRunTestable(tt.n1, tt.n2, tt.alpha); got != tt.ok {
+			t.Errorf("perRunTestable(%d, %d, %g) = %v, ожидается %v", tt.n1, tt.n2, tt.alpha, got, tt.ok)
+		}
+		// при полном разделении точный тест действительно даёт это p
+		x, y := make([]float64, tt.n1), make([]float64, tt.n2)
+		for i := range x {
+			x[i] = float64(i)
+		}
+		for i := range y {
+			y[i] = float64(tt.n1 + i)
+		}
+		if _, p := MannWhitneyU(x, y); math.Abs(p-tt.want) > 1e-12 {
+			t.Errorf("n1=%d n2=%d: p при разделении %g, ожидается %g", tt.n1, tt.n2, p, tt.want)
+		}
+	}
+}
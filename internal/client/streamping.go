@@ -4,109 +4,330 @@ import (
 	"context"
 	"io"
 	"log"
-	"math/rand"
-	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
 )
 
-func StreamPing(client pb.BenchmarkServiceClient, totalRequests int, concurrency int, scenario LoadScenario) {
+// StreamPing — бенчмарк двунаправленного потока. При opts.StreamWindow = 0
+// каждая итерация открывает новый поток, иначе потоки долгоживущие,
+// см. LongStreamPingWorkload.
+func StreamPing(client pb.BenchmarkServiceClient, opts LoadOptions) (*Result, error) {
 	log.Println("=== Bidirectional StreamPing ===")
-	var wg sync.WaitGroup
-	wg.Add(concurrency)
-
-	rand.Seed(time.Now().UnixNano())
-	var latencies []time.Duration
-	var mu sync.Mutex
-	var successCount, failCount int64
-
-	for g := 0; g < concurrency; g++ {
-		go func(workerID int) {
-			defer wg.Done()
-			for i := 0; i < totalRequests/concurrency; i++ {
-				reqStart := time.Now()
-				stream, err := client.StreamPing(context.Background())
-				if err != nil {
-					mu.Lock()
-					failCount++
-					mu.Unlock()
-					log.Printf("Worker %d: Не удалось открыть StreamPing: %v", workerID, err)
-					continue
-				}
+	w, err := RPCWorkload(client, "StreamPing", opts)
+	if err != nil {
+		return nil, err
+	}
+	return RunWorkload(w, opts)
+}
 
-				// Получение ответов
-				done := make(chan struct{})
-				go func() {
-					for {
-						resp, err := stream.Recv()
-						if err == io.EOF {
-							break
-						}
-						if err != nil {
-							log.Printf("Worker %d: Ошибка получения StreamPing: %v", workerID, err)
-							break
-						}
-						LogDebug("Worker %d: StreamPing response: %s", workerID, resp.Message)
+// StreamPingWorkload — двунаправленные потоки: в каждой итерации
+// новый поток с одним сообщением и ответом на него
+func StreamPingWorkload(client pb.BenchmarkServiceClient, payload Payload) Workload {
+	return FuncWorkload{
+		Label:      "StreamPing",
+		FullMethod: pb.BenchmarkService_StreamPing_FullMethodName,
+		Fn: func(ctx context.Context, it Iteration) error {
+			workerID := it.Worker
+			stream, err := client.StreamPing(ctx)
+			if err != nil {
+				log.Printf("Worker %d: Не удалось открыть StreamPing: %v", workerID, err)
+				return WithPhase(PhaseDial, err)
+			}
+
+			// Получение ответов
+			done := make(chan error, 1)
+			go func() {
+				for {
+					resp, err := stream.Recv()
+					if err == io.EOF {
+						done <- nil
+						return
 					}
-					close(done)
-				}()
-
-				msg := "stream ping #" + strconv.Itoa(i+1)
-				if err := stream.Send(&pb.PingRequest{Message: msg}); err != nil {
-					mu.Lock()
-					failCount++
-					mu.Unlock()
-					log.Printf("Worker %d: Ошибка отправки StreamPing: %v", workerID, err)
-				} else {
-					mu.Lock()
-					successCount++
-					mu.Unlock()
-					LogDebug("Worker %d: Отправлено StreamPing: %s", workerID, msg)
+					if err != nil {
+						log.Printf("Worker %d: Ошибка получения StreamPing: %v", workerID, err)
+						done <- err
+						return
+					}
+					LogDebug("Worker %d: StreamPing response: %s", workerID, resp.Message)
 				}
+			}()
 
-				if err := stream.CloseSend(); err != nil {
-					log.Printf("Worker %d: Ошибка закрытия StreamPing: %v", workerID, err)
-				}
-				<-done
-				latency := time.Since(reqStart)
-				mu.Lock()
-				latencies = append(latencies, latency)
-				mu.Unlock()
-
-				// Симуляция сценариев нагрузки
-				switch scenario {
-				case ScenarioLight:
-					time.Sleep(100 * time.Millisecond)
-				case ScenarioPeak:
-					time.Sleep(time.Duration(rand.Intn(10)) * time.Millisecond)
-				case ScenarioConstant:
-					// без пауз
+			msg := payload.message("stream ping #" + strconv.Itoa(it.Seq+1))
+			if err := stream.Send(payload.request(msg)); err != nil {
+				if err == io.EOF {
+					// Поток закрыт сервером, его статус возвращает Recv
+					if rerr := <-done; rerr != nil {
+						err = rerr
+					}
 				}
+				log.Printf("Worker %d: Ошибка отправки StreamPing: %v", workerID, err)
+				return WithPhase(PhaseSend, err)
+			}
+			LogDebug("Worker %d: Отправлено StreamPing: %s", workerID, msg)
+
+			if err := stream.CloseSend(); err != nil {
+				log.Printf("Worker %d: Ошибка закрытия StreamPing: %v", workerID, err)
 			}
-		}(g)
+			return WithPhase(PhaseRecv, <-done)
+		},
+	}
+}
+
+// MetricStreamSetup — время открытия потока в Result.Metrics
+const MetricStreamSetup = "stream_setup"
+
+// LongStreamPingWorkload — долгоживущие двунаправленные потоки. Итерация —
+// одно сообщение, её задержка — от отправки до ответа именно на это
+// сообщение: запрос и ответ сопоставляются по номеру в начале сообщения,
+// который сервер возвращает в эхо.
+//
+// Каждый поток обслуживают window воркеров, поэтому в потоке одновременно
+// не больше window сообщений: window = 1 — ping-pong, больше — конвейер.
+// Всего потоков concurrency/window (с округлением вверх). Потоки
+// открываются в Setup и переоткрываются первой итерацией после ошибки.
+// Время открытия попадает только в метрику MetricStreamSetup: из задержки
+// сообщения, открывшего поток, оно вычитается.
+func LongStreamPingWorkload(client pb.BenchmarkServiceClient, payload Payload, concurrency, window int) Workload {
+	if window < 1 {
+		window = 1
+	}
+	w := &longStreamPing{client: client, payload: payload, window: window}
+	w.streams = make([]*pingStream, (concurrency+window-1)/window)
+	for i := range w.streams {
+		w.streams[i] = &pingStream{sendTurn: make(chan struct{}, 1)}
 	}
+	return w
+}
+
+type longStreamPing struct {
+	client  pb.BenchmarkServiceClient
+	payload Payload
+	window  int
+	streams []*pingStream
+	metrics MetricSet
+}
+
+// pingStream — один долгоживущий поток и сообщения, ждущие ответа
+type pingStream struct {
+	mu       sync.Mutex    // защищает поля ниже; на время Send не держится
+	sendTurn chan struct{} // очередь на Send: gRPC не разрешает параллельную отправку в поток
+	stream   pb.BenchmarkService_StreamPingClient
+	cancel   context.CancelFunc
+	recvEnd  chan struct{} // закрывается, когда горутина чтения завершилась
+	nextID   uint64
+	pending  map[uint64]chan error
+}
+
+func (w *longStreamPing) Name() string   { return "StreamPing" }
+func (w *longStreamPing) Method() string { return pb.BenchmarkService_StreamPing_FullMethodName }
+
+// Setup открывает потоки заранее, чтобы рукопожатие не попало в первые
+// сообщения. Поток, который открыть не удалось, откроет итерация: её
+// ошибка будет учтена как ошибка вызова.
+func (w *longStreamPing) Setup(context.Context) error {
+	for _, s := range w.streams {
+		s.mu.Lock()
+		if s.stream != nil {
+			s.mu.Unlock()
+			continue
+		}
+		setup, err := w.open(s)
+		s.mu.Unlock()
+		if err != nil {
+			log.Printf("Не удалось открыть StreamPing: %v", err)
+			continue
+		}
+		w.metrics.Record(MetricStreamSetup, setup)
+	}
+	return nil
+}
 
-	wg.Wait()
+func (w *longStreamPing) Execute(ctx context.Context, it Iteration) error {
+	s := w.streams[it.Worker/w.window]
+	id, wait, err := w.send(ctx, s, it)
+	if err != nil {
+		return err
+	}
+	select {
+	case err := <-wait:
+		return err
+	case <-ctx.Done():
+		s.forget(id)
+		return WithPhase(PhaseRecv, status.FromContextError(ctx.Err()).Err())
+	}
+}
 
-	// Расчет p50, p90, p99
-	var p50, p90, p99 time.Duration
-	if len(latencies) > 0 {
-		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
-		idx := func(p float64) int {
-			i := int(float64(len(latencies)) * p / 100.0)
-			if i >= len(latencies) {
-				i = len(latencies) - 1
+// Teardown закрывает отправку во всех потоках и дожидается их завершения
+func (w *longStreamPing) Teardown(context.Context) error {
+	for _, s := range w.streams {
+		s.mu.Lock()
+		stream, recvEnd := s.stream, s.recvEnd
+		if stream != nil {
+			if err := stream.CloseSend(); err != nil {
+				log.Printf("Ошибка закрытия StreamPing: %v", err)
 			}
-			return i
 		}
-		p50 = latencies[idx(50)]
-		p90 = latencies[idx(90)]
-		p99 = latencies[idx(99)]
+		s.mu.Unlock()
+		if recvEnd != nil {
+			<-recvEnd
+		}
 	}
+	return nil
+}
 
-	log.Printf("StreamPing: Всего запросов: %d, успешных: %d, неуспешных: %d", totalRequests, successCount, failCount)
-	log.Printf("StreamPing: Latency p50: %s, p90: %s, p99: %s", p50, p90, p99)
+func (w *longStreamPing) Metrics() map[string]*Histogram {
+	return w.metrics.Histograms()
 }
+
+// send отправляет сообщение итерации, при необходимости открыв поток,
+// и возвращает канал, в который придёт итог ответа.
+//
+// Воркеры потока отправляют по очереди, но s.mu на время Send не держат,
+// чтобы ответы продолжали раздаваться. Если Send заблокирован управлением
+// потоком дольше, чем живёт ctx итерации, поток сбрасывается: иначе
+// вместе с ним встанут все воркеры этого потока.
+func (w *longStreamPing) send(ctx context.Context, s *pingStream, it Iteration) (uint64, chan error, error) {
+	s.mu.Lock()
+	if s.stream == nil {
+		setup, err := w.open(s)
+		if err != nil {
+			s.mu.Unlock()
+			log.Printf("Worker %d: Не удалось открыть StreamPing: %v", it.Worker, err)
+			return 0, nil, WithPhase(PhaseDial, err)
+		}
+		ExcludeFromLatency(ctx, setup)
+		if it.Measured {
+			w.metrics.Record(MetricStreamSetup, setup)
+		}
+	}
+	stream := s.stream
+	id := s.nextID
+	s.nextID++
+	wait := make(chan error, 1)
+	s.pending[id] = wait
+	s.mu.Unlock()
+
+	select {
+	case s.sendTurn <- struct{}{}:
+	case <-ctx.Done():
+		s.forget(id)
+		return 0, nil, WithPhase(PhaseSend, status.FromContextError(ctx.Err()).Err())
+	}
+	stop := context.AfterFunc(ctx, func() {
+		s.reset(stream, WithPhase(PhaseSend, status.Error(codes.Canceled, "поток сброшен: отправка в него не уложилась в таймаут")))
+	})
+	msg := strconv.FormatUint(id, 10) + " " + w.payload.message("stream ping #"+strconv.Itoa(it.Seq+1))
+	err := stream.Send(w.payload.request(msg))
+	stop()
+	<-s.sendTurn
+
+	if err == io.EOF {
+		// Поток уже закрыт или сброшен: его статус получат все ожидающие
+		// сообщения, в том числе это
+		select {
+		case err := <-wait:
+			return 0, nil, err
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = status.FromContextError(ctx.Err()).Err()
+		}
+		log.Printf("Worker %d: Ошибка отправки StreamPing: %v", it.Worker, err)
+		// Поток оборван: горутина чтения получит его статус, завершит
+		// остальные ожидающие сообщения и сбросит поток
+		s.forget(id)
+		return 0, nil, WithPhase(PhaseSend, err)
+	}
+	LogDebug("Worker %d: Отправлено StreamPing: %s", it.Worker, msg)
+	return id, wait, nil
+}
+
+// open открывает поток, запускает чтение ответов и возвращает время
+// открытия; s.mu захвачен
+func (w *longStreamPing) open(s *pingStream) (time.Duration, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	stream, err := w.client.StreamPing(ctx)
+	if err != nil {
+		cancel()
+		return 0, err
+	}
+	setup := time.Since(start)
+	s.stream, s.cancel = stream, cancel
+	s.recvEnd = make(chan struct{})
+	s.pending = map[uint64]chan error{}
+	go s.receive(stream, s.recvEnd)
+	return setup, nil
+}
+
+// receive раздаёт ответы ожидающим сообщениям, пока поток не закроется
+func (s *pingStream) receive(stream pb.BenchmarkService_StreamPingClient, end chan struct{}) {
+	defer close(end)
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				err = status.Error(codes.Unavailable, "поток закрыт сервером")
+			}
+			s.reset(stream, WithPhase(PhaseRecv, err))
+			return
+		}
+		id, ok := echoID(resp.Message)
+		if !ok {
+			LogDebug("StreamPing: ответ без номера сообщения: %s", resp.Message)
+			continue
+		}
+		s.mu.Lock()
+		wait := s.pending[id]
+		delete(s.pending, id)
+		s.mu.Unlock()
+		if wait != nil {
+			wait <- nil
+		}
+	}
+}
+
+// reset завершает ожидающие сообщения ошибкой err и сбрасывает поток,
+// чтобы следующая итерация открыла новый
+func (s *pingStream) reset(stream pb.BenchmarkService_StreamPingClient, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream != stream {
+		return
+	}
+	for id, wait := range s.pending {
+		wait <- err
+		delete(s.pending, id)
+	}
+	s.cancel()
+	s.stream = nil
+}
+
+// forget снимает ожидание ответа на сообщение, по которому истёк таймаут
+func (s *pingStream) forget(id uint64) {
+	s.mu.Lock()
+	delete(s.pending, id)
+	s.mu.Unlock()
+}
+
+// echoID извлекает номер сообщения из ответа сервера вида
+// "echo: <номер> ..." или "echo: error: <номер> ..."
+func echoID(msg string) (uint64, bool) {
+	msg = strings.TrimPrefix(msg, "echo: ")
+	msg = strings.TrimPrefix(msg, "error: ")
+	num, _, _ := strings.Cut(msg, " ")
+	id, err := strconv.ParseUint(num, 10, 64)
+	return id, err == nil
+}
+
+var _ MetricsWorkload = (*longStreamPing)(nil)
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// stalledServer открывает StreamPing, но не читает из него, поэтому
+// отправка клиента упирается в управление потоком
+type stalledServer struct {
+	pb.UnimplementedBenchmarkServiceServer
+}
+
+func (stalledServer) StreamPing(stream pb.BenchmarkService_StreamPingServer) error {
+	<-stream.Context().Done()
+	return nil
+}
+
+// Send, заблокированный управлением потоком, не должен держать итерацию
+// дольше её таймаута и останавливать остальных воркеров потока
+func TestLongStreamPingStalledSend(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterBenchmarkServiceServer(srv, stalledServer{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	size, err := ParseSize("1MiB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const window = 4
+	w := LongStreamPingWorkload(pb.NewBenchmarkServiceClient(conn), Payload{Size: size}, window, window)
+
+	const timeout = 200 * time.Millisecond
+	var wg sync.WaitGroup
+	errs := make([]error, window)
+	elapsed := make([]time.Duration, window)
+	for i := 0; i < window; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			start := time.Now()
+			errs[worker] = w.Execute(ctx, Iteration{Worker: worker, Seq: worker})
+			elapsed[worker] = time.Since(start)
+		}(i)
+	}
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("воркеры потока зависли на отправке")
+	}
+
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("воркер %d: ожидается ошибка, сервер не отвечает", i)
+			continue
+		}
+		if code := status.Code(err); code != codes.DeadlineExceeded && code != codes.Canceled {
+			t.Errorf("воркер %d: код %s (%v), ожидается DeadlineExceeded или Canceled", i, code, err)
+		}
+		if elapsed[i] > timeout+time.Second {
+			t.Errorf("воркер %d вернулся через %s при таймауте %s", i, elapsed[i], timeout)
+		}
+	}
+	w.Teardown(context.Background())
+}
+
+// Открытие потока учитывается только в MetricStreamSetup, а не в
+// задержке сообщений: ни первых, ни первого после сброса потока
+func TestLongStreamPingSetupNotInLatency(t *testing.T) {
+	const setup = 50 * time.Millisecond
+	slowOpen := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		time.Sleep(setup)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	c := pb.NewBenchmarkServiceClient(benchConn(t, echoServer{}, grpc.WithStreamInterceptor(slowOpen)))
+	const concurrency, window = 4, 2
+	w := LongStreamPingWorkload(c, Payload{}, concurrency, window)
+	res, err := RunWorkload(w, LoadOptions{Scenario: ScenarioConstant, Concurrency: 1, Requests: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max := res.Histogram.Max(); max >= setup {
+		t.Errorf("наибольшая задержка %s включает открытие потока %s", max, setup)
+	}
+	if n := res.Metrics[MetricStreamSetup].Count; n != concurrency/window {
+		t.Errorf("%s: %d значений, ожидается %d", MetricStreamSetup, n, concurrency/window)
+	}
+
+	// Сброшенный поток открывает итерация, но задержка её сообщения
+	// открытия не включает. Setup повторно не вызывается.
+	s := w.(*longStreamPing).streams[0]
+	s.mu.Lock()
+	stream := s.stream
+	s.mu.Unlock()
+	s.reset(stream, status.Error(codes.Unavailable, "сброс"))
+	iterations := FuncWorkload{Label: w.Name(), Fn: w.Execute}
+	res, err = RunWorkload(iterations, LoadOptions{Scenario: ScenarioConstant, Concurrency: 1, Requests: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max := res.Histogram.Max(); res.Success != 3 || max >= setup {
+		t.Errorf("успешных %d, наибольшая задержка %s; ожидается 3 и меньше %s", res.Success, max, setup)
+	}
+	if err := w.Teardown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEchoID(t *testing.T) {
+	tests := []struct {
+		msg string
+		id  uint64
+		ok  bool
+	}{
+		{"echo: 42 stream ping #1", 42, true},
+		{"echo: error: 7 stream ping #3", 7, true},
+		{"0", 0, true},
+		{"echo: stream ping #1", 0, false},
+		{"echo: ", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		if id, ok := echoID(tt.msg); id != tt.id || ok != tt.ok {
+			t.Errorf("echoID(%q) = %d, %v; ожидается %d, %v", tt.msg, id, ok, tt.id, tt.ok)
+		}
+	}
+}
+
+// reorderServer принимает по window сообщений и отвечает на них в обратном
+// порядке с паузой gap между ответами, предварив их ответами без номера
+// и с чужим номером
+type reorderServer struct {
+	echoServer
+	window int
+	gap    time.Duration
+
+	mu       sync.Mutex
+	received []string // сообщения в порядке получения
+}
+
+func (s *reorderServer) StreamPing(stream pb.BenchmarkService_StreamPingServer) error {
+	var batch []string
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+		s.mu.Lock()
+		s.received = append(s.received, req.Message)
+		s.mu.Unlock()
+		if batch = append(batch, req.Message); len(batch) < s.window {
+			continue
+		}
+		for _, msg := range []string{"echo: no id", "echo: 999 stale"} {
+			if err := stream.Send(&pb.PingResponse{Message: msg}); err != nil {
+				return err
+			}
+		}
+		for i := len(batch) - 1; i >= 0; i-- {
+			if i < len(batch)-1 {
+				time.Sleep(s.gap)
+			}
+			if err := stream.Send(&pb.PingResponse{Message: "echo: " + batch[i]}); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+	}
+}
+
+// При window > 1 ответы сопоставляются сообщениям по номеру, а не по
+// порядку: итерация ждёт ответа на своё сообщение, даже если ответ на
+// отправленное позже пришёл раньше
+func TestLongStreamPingMatchesResponsesByID(t *testing.T) {
+	const window, gap = 3, 30 * time.Millisecond
+	srv := &reorderServer{window: window, gap: gap}
+	client := pb.NewBenchmarkServiceClient(benchConn(t, srv))
+	w := LongStreamPingWorkload(client, Payload{}, window, window)
+	if err := w.Setup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	done := make(map[string]time.Time, window)
+	var mu sync.Mutex
+	for i := 0; i < window; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := w.Execute(ctx, Iteration{Worker: i, Seq: i, Measured: true}); err != nil {
+				t.Errorf("итерация %d: %v", i, err)
+			}
+			mu.Lock()
+			done["stream ping #"+strconv.Itoa(i+1)] = time.Now()
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if err := w.Teardown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Сервер ответил на сообщения в обратном порядке получения: в том же
+	// порядке должны завершиться итерации
+	if len(srv.received) != window {
+		t.Fatalf("сервер получил %d сообщений", len(srv.received))
+	}
+	for i := 1; i < window; i++ {
+		_, earlier, _ := strings.Cut(srv.received[i-1], " ")
+		_, later, _ := strings.Cut(srv.received[i], " ")
+		if d := done[earlier].Sub(done[later]); d < gap/2 {
+			t.Errorf("%q получено раньше %q, но завершилось через %v после него, ожидается около %v", earlier, later, d, gap)
+		}
+	}
+}
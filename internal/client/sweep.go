@@ -0,0 +1,159 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/payload"
+)
+
+// Какой размер payload меняет серия прогонов Sweep
+const (
+	SweepRequest  = "request"
+	SweepResponse = "response"
+	SweepBoth     = "both"
+)
+
+// ParseSweepSizes разбирает размеры серии: список "64,1KiB,64KiB" или
+// диапазон "64B..4MiB" с шагом ×2 ("64B..4MiB*4" — с шагом ×4).
+// Размеры больше payload.MaxSize отклоняются.
+func ParseSweepSizes(spec string) ([]int, error) {
+	size := func(v string) (int, error) {
+		n, err := parseBytes(v)
+		if err == nil && n > payload.MaxSize {
+			err = fmt.Errorf("серия %q: размер %s больше максимального %s", spec, FormatBytes(n), FormatBytes(payload.MaxSize))
+		}
+		return n, err
+	}
+	from, rest, isRange := strings.Cut(spec, "..")
+	if !isRange {
+		var sizes []int
+		for _, v := range strings.Split(spec, ",") {
+			n, err := size(v)
+			if err != nil {
+				return nil, err
+			}
+			sizes = append(sizes, n)
+		}
+		return sizes, nil
+	}
+
+	to, step, ok := strings.Cut(rest, "*")
+	factor := 2
+	if ok {
+		if _, err := fmt.Sscan(step, &factor); err != nil || factor < 2 {
+			return nil, fmt.Errorf("серия %q: шаг должен быть целым числом не меньше 2", spec)
+		}
+	}
+	lo, err := size(from)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := size(to)
+	if err != nil {
+		return nil, err
+	}
+	if lo <= 0 || lo > hi {
+		return nil, fmt.Errorf("серия %q: нужен диапазон от положительного размера до большего", spec)
+	}
+	sizes := []int{lo}
+	// n*factor ≤ hi проверяется делением, чтобы умножение не переполнилось
+	for n := lo; n <= hi/factor; {
+		n *= factor
+		sizes = append(sizes, n)
+	}
+	return sizes, nil
+}
+
+// Sweep размножает этап на серию этапов с фиксированным размером payload
+// из sizes. side — какой размер меняется: SweepRequest, SweepResponse
+// или SweepBoth; второй размер остаётся как в этапе.
+func Sweep(st Stage, sizes []int, side string) ([]Stage, error) {
+	if side == "" {
+		side = SweepRequest
+	}
+	if side != SweepRequest && side != SweepResponse && side != SweepBoth {
+		return nil, fmt.Errorf("неизвестная сторона серии %q, доступны: request, response, both", side)
+	}
+	stages := make([]Stage, len(sizes))
+	for i, n := range sizes {
+		s := st
+		if side != SweepResponse {
+			s.Load.Payload.Size = FixedSize(n)
+		}
+		if side != SweepRequest {
+			s.Load.Payload.ResponseSize = FixedSize(n)
+		}
+		s.Load.Sweep = side
+		stages[i] = s
+	}
+	return stages, nil
+}
+
+// writeSweepCurves выводит результаты серий по размеру одной таблицей на
+// бенчмарк: как RPS, пропускная способность и задержка зависят от размера.
+// МиБ/с — RPS, умноженный на средний размер payload запроса и ответа.
+func writeSweepCurves(w io.Writer, results []*Result) error {
+	type curve struct {
+		title  string
+		points []*Result
+	}
+	var curves []*curve
+	index := map[string]*curve{}
+	for _, r := range results {
+		if r.Params.Sweep == "" {
+			continue
+		}
+		title := r.Name
+		if r.Stage != "" {
+			title += ", этап " + r.Stage
+		}
+		if r.Target != "" {
+			title += ", цель " + r.Target
+		}
+		if r.Run > 0 {
+			title += fmt.Sprintf(", повтор %d", r.Run)
+		}
+		if r.Params.Compression != "" || r.Params.PayloadFill != "" {
+			title += fmt.Sprintf(", сжатие %s, payload %s", compressionLabel(r.Params.Compression), fillLabel(r.Params.PayloadFill))
+		}
+		c, ok := index[title]
+		if !ok {
+			c = &curve{title: title}
+			index[title] = c
+			curves = append(curves, c)
+		}
+		c.points = append(c.points, r)
+	}
+
+	for _, c := range curves {
+		fmt.Fprintf(w, "=== Размер payload: %s (%s) ===\n", c.title, c.points[0].Params.Sweep)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "запрос\tответ\tRPS\tМиБ/с\tp50\tp99\tошибки")
+		for _, r := range c.points {
+			req, _ := ParseSize(r.Params.RequestSize)
+			resp, _ := ParseSize(r.Params.ResponseSize)
+			mib := r.RPS * (req.Mean() + resp.Mean()) / (1 << 20)
+			p50, _ := r.Latency.Percentile(50)
+			p99, _ := r.Latency.Percentile(99)
+			fmt.Fprintf(tw, "%s\t%s\t%.2f\t%.2f\t%s\t%s\t%.2f%%\n",
+				sizeLabel(r.Params.RequestSize), sizeLabel(r.Params.ResponseSize), r.RPS, mib, p50, p99, errorRate(r))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sizeLabel(spec string) string {
+	if spec == "" {
+		return "0B"
+	}
+	return spec
+}
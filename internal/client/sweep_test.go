@@ -0,0 +1,82 @@
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSweepSizes(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []int
+		wantErr string
+	}{
+		{spec: "64,1KiB,64KiB", want: []int{64, 1 << 10, 64 << 10}},
+		{spec: "64B..1KiB", want: []int{64, 128, 256, 512, 1024}},
+		{spec: "64B..1000", want: []int{64, 128, 256, 512}},
+		{spec: "64B..1MiB*16", want: []int{64, 1 << 10, 16 << 10, 256 << 10}},
+		{spec: "1KiB..1KiB", want: []int{1 << 10}},
+		{spec: "16MiB", want: []int{16 << 20}},
+		// шаг, при котором умножение переполнило бы int
+		{spec: "2..4MiB*4611686018427387904", want: []int{2}},
+		{spec: "1..16MiB*9223372036854775807", want: []int{1}},
+		{spec: "64..1KiB*1", wantErr: "шаг"},
+		{spec: "64..1KiB*x", wantErr: "шаг"},
+		{spec: "1KiB..64", wantErr: "диапазон"},
+		{spec: "0..64", wantErr: "диапазон"},
+		{spec: "64..32MiB", wantErr: "больше максимального"},
+		{spec: "64,17MiB", wantErr: "больше максимального"},
+		{spec: "64,1GiB", wantErr: "неизвестная единица"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseSweepSizes(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидается %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("размеры %v, ожидается %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSweep(t *testing.T) {
+	base := Stage{Name: "s", RPC: "Ping"}
+	base.Load.Payload.Size = FixedSize(10)
+	base.Load.Payload.ResponseSize = FixedSize(20)
+	tests := []struct {
+		side          string
+		request, resp []string
+		wantSide      string
+	}{
+		{"", []string{"64B", "1KiB"}, []string{"20B", "20B"}, SweepRequest},
+		{SweepResponse, []string{"10B", "10B"}, []string{"64B", "1KiB"}, SweepResponse},
+		{SweepBoth, []string{"64B", "1KiB"}, []string{"64B", "1KiB"}, SweepBoth},
+	}
+	for _, tt := range tests {
+		stages, err := Sweep(base, []int{64, 1024}, tt.side)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, st := range stages {
+			p := st.Load.Payload
+			if p.Size.String() != tt.request[i] || p.ResponseSize.String() != tt.resp[i] || st.Load.Sweep != tt.wantSide || st.Name != "s" {
+				t.Errorf("%q, этап %d: запрос %s, ответ %s, серия %q", tt.side, i, p.Size, p.ResponseSize, st.Load.Sweep)
+			}
+		}
+	}
+	if base.Load.Sweep != "" || base.Load.Payload.Size.String() != "10B" {
+		t.Error("Sweep изменил исходный этап")
+	}
+	if _, err := Sweep(base, []int{64}, "both-ways"); err == nil {
+		t.Error("неизвестная сторона серии принята")
+	}
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Snapshot — метрики одного интервала прогона. Запросы относятся
+// к интервалу, в котором они завершились; учитываются все фазы,
+// запросы разогрева и остывания дополнительно считаются в Discarded.
+type Snapshot struct {
+	Start     time.Time      `json:"start"`
+	Offset    time.Duration  `json:"offset_ns"` // начало интервала от старта прогона
+	Length    time.Duration  `json:"length_ns"`
+	Success   int64          `json:"success"`
+	Failures  int64          `json:"failures"`
+	Discarded int64          `json:"discarded,omitempty"`
+	RPS       float64        `json:"rps"`
+	ErrorRate float64        `json:"error_rate"` // доля ошибок, %
+	Latency   LatencySummary `json:"latency"`
+}
+
+// intervalStats — счётчики воркера за текущий интервал. В отличие
+// от workerStats их периодически забирает горутина timeline,
+// поэтому доступ защищён мьютексом; конкуренции за него почти нет.
+type intervalStats struct {
+	mu        sync.Mutex
+	success   int64
+	fail      int64
+	discarded int64
+	latency   *Histogram
+}
+
+func (s *intervalStats) record(latency time.Duration, err error, measured bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.fail++
+	} else {
+		s.success++
+		s.latency.Record(latency)
+	}
+	if !measured {
+		s.discarded++
+	}
+}
+
+// collect переносит счётчики интервала в snap и обнуляет их
+func (s *intervalStats) collect(snap *Snapshot, latency *Histogram) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap.Success += s.success
+	snap.Failures += s.fail
+	snap.Discarded += s.discarded
+	latency.Merge(s.latency)
+	s.success, s.fail, s.discarded = 0, 0, 0
+	s.latency = NewHistogram()
+}
+
+// timeline раз в интервал собирает снимки со всех воркеров
+// и сразу выводит их в лог
+type timeline struct {
+	name      string
+	interval  time.Duration
+	start     time.Time
+	last      time.Time
+	workers   []*intervalStats
+	snapshots []Snapshot
+	observe   IntervalFunc
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// IntervalFunc получает каждый снимок прогона name вместе с гистограммой
+// задержек интервала. Вызывается из горутины снимков и не должна её
+// надолго задерживать.
+type IntervalFunc func(name string, snap Snapshot, latency *Histogram)
+
+func newTimeline(name string, interval time.Duration, workers int, observe IntervalFunc) *timeline {
+	t := &timeline{
+		name:     name,
+		interval: interval,
+		observe:  observe,
+		start:    time.Now(),
+		workers:  make([]*intervalStats, workers),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	t.last = t.start
+	for i := range t.workers {
+		t.workers[i] = &intervalStats{latency: NewHistogram()}
+	}
+	go t.run()
+	return t
+}
+
+func (t *timeline) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			t.snapshot(now, false)
+		case <-t.stop:
+			t.snapshot(time.Now(), true)
+			return
+		}
+	}
+}
+
+// snapshot закрывает текущий интервал. Последний неполный интервал
+// без запросов отбрасывается.
+func (t *timeline) snapshot(now time.Time, final bool) {
+	snap := Snapshot{Start: t.last, Offset: t.last.Sub(t.start), Length: now.Sub(t.last)}
+	latency := NewHistogram()
+	for _, w := range t.workers {
+		w.collect(&snap, latency)
+	}
+	t.last = now
+
+	total := snap.Success + snap.Failures
+	if final && total == 0 {
+		return
+	}
+	if snap.Length > 0 {
+		snap.RPS = float64(snap.Success) / snap.Length.Seconds()
+	}
+	if total > 0 {
+		snap.ErrorRate = float64(snap.Failures) / float64(total) * 100
+	}
+	snap.Latency = Summarize(latency)
+	t.snapshots = append(t.snapshots, snap)
+	LogInfo("%s %s", t.name, formatSnapshot(snap))
+	if t.observe != nil {
+		t.observe(t.name, snap, latency)
+	}
+}
+
+// finish останавливает сбор и возвращает все снимки
+func (t *timeline) finish() []Snapshot {
+	close(t.stop)
+	<-t.done
+	return t.snapshots
+}
+
+// formatSnapshot — строка снимка для живого вывода и текстового отчёта
+func formatSnapshot(s Snapshot) string {
+	p50, _ := s.Latency.Percentile(50)
+	p99, _ := s.Latency.Percentile(99)
+	parts := []string{
+		"+" + s.Offset.Round(time.Millisecond).String(),
+		"rps=" + strconv.FormatFloat(s.RPS, 'f', 1, 64),
+		"ошибки=" + strconv.FormatFloat(s.ErrorRate, 'f', 2, 64) + "%",
+		"p50=" + p50.String(),
+		"p99=" + p99.String(),
+		"max=" + s.Latency.Max.String(),
+	}
+	if s.Discarded > 0 {
+		parts = append(parts, "разогрев/остывание="+strconv.FormatInt(s.Discarded, 10))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIntervalStatsCollect(t *testing.T) {
+	s := &intervalStats{latency: NewHistogram()}
+	s.record(10*time.Millisecond, nil, true)
+	s.record(20*time.Millisecond, nil, false)
+	s.record(0, errors.New("fail"), true)
+
+	var snap Snapshot
+	latency := NewHistogram()
+	s.collect(&snap, latency)
+	if snap.Success != 2 || snap.Failures != 1 || snap.Discarded != 1 {
+		t.Fatalf("снимок %+v", snap)
+	}
+	if got := latency.Count(); got != 2 {
+		t.Errorf("в гистограмме %d значений, ожидается 2 (ошибки не учитываются)", got)
+	}
+
+	// после collect счётчики интервала обнулены
+	var next Snapshot
+	s.collect(&next, NewHistogram())
+	if next.Success != 0 || next.Failures != 0 || next.Discarded != 0 {
+		t.Errorf("счётчики не обнулены: %+v", next)
+	}
+}
+
+func TestTimelineSnapshot(t *testing.T) {
+	tests := []struct {
+		name      string
+		record    func(tl *timeline)
+		snapshots int
+		errorRate float64
+	}{
+		{"пустой прогон", func(*timeline) {}, 0, 0},
+		{"запросы всех воркеров", func(tl *timeline) {
+			tl.workers[0].record(time.Millisecond, nil, true)
+			tl.workers[1].record(2*time.Millisecond, nil, true)
+			tl.workers[1].record(0, errors.New("fail"), true)
+			tl.workers[1].record(0, errors.New("fail"), true)
+		}, 1, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var observed []Snapshot
+			tl := newTimeline("Ping", time.Hour, 2, func(name string, snap Snapshot, latency *Histogram) {
+				if name != "Ping" || latency.Count() != snap.Success {
+					t.Errorf("observe(%q): гистограмма %d, успешных %d", name, latency.Count(), snap.Success)
+				}
+				observed = append(observed, snap)
+			})
+			tt.record(tl)
+			snaps := tl.finish()
+			if len(snaps) != tt.snapshots || len(observed) != tt.snapshots {
+				t.Fatalf("снимков %d, передано в observe %d, ожидается %d", len(snaps), len(observed), tt.snapshots)
+			}
+			if tt.snapshots == 0 {
+				return
+			}
+			s := snaps[0]
+			if s.Success != 2 || s.Failures != 2 || s.ErrorRate != tt.errorRate {
+				t.Errorf("снимок %+v", s)
+			}
+			if s.Offset != 0 || s.Length <= 0 || s.RPS <= 0 {
+				t.Errorf("интервал: offset %v, length %v, rps %g", s.Offset, s.Length, s.RPS)
+			}
+			if s.Latency.Max < 2*time.Millisecond {
+				t.Errorf("max %v, ожидается не меньше 2ms", s.Latency.Max)
+			}
+		})
+	}
+}
+
+func TestTimelineInterval(t *testing.T) {
+	tl := newTimeline("Ping", 10*time.Millisecond, 1, nil)
+	tl.workers[0].record(time.Millisecond, nil, true)
+	time.Sleep(35 * time.Millisecond)
+	snaps := tl.finish()
+	if len(snaps) < 2 {
+		t.Fatalf("снимков %d, ожидается несколько интервалов", len(snaps))
+	}
+	var success int64
+	for i, s := range snaps {
+		success += s.Success
+		if i > 0 && s.Offset != snaps[i-1].Offset+snaps[i-1].Length {
+			t.Errorf("интервал %d начинается с %v, предыдущий закончился на %v", i, s.Offset, snaps[i-1].Offset+snaps[i-1].Length)
+		}
+	}
+	if success != 1 {
+		t.Errorf("запрос учтён %d раз", success)
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SlowestTraces — сколько самых медленных вызовов с записанной трассой
+// попадает в Result.SlowestTraces
+const SlowestTraces = 10
+
+// tracer создаёт span на каждую итерацию нагрузки. Пока глобальный
+// TracerProvider не задан, span'ы пустые и ничего не стоят.
+var tracer = otel.Tracer("github.com/go-portfolio/go-grpc-benchmark/internal/client")
+
+// TracedCall — успешный вызов, попавший в выборку трассировки.
+// По TraceID его можно найти в Jaeger вместе со span'ами сервера.
+type TracedCall struct {
+	TraceID string        `json:"trace_id"`
+	Start   time.Time     `json:"start"` // запланированное время отправки
+	Latency time.Duration `json:"latency_ns"`
+	Worker  int           `json:"worker"`
+}
+
+// startSpan начинает span итерации r. Span начинается с запланированного
+// времени отправки, поэтому, как и задержка в отчёте, включает ожидание
+// в очереди open-loop. Вызов gRPC внутри итерации становится дочерним
+// span'ом, если соединение подключено с otelgrpc.NewClientHandler.
+func startSpan(ctx context.Context, w Workload, r request) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, w.Name(), trace.WithTimestamp(r.intended))
+	if span.IsRecording() {
+		span.SetAttributes(
+			attribute.String("bench.method", w.Method()),
+			attribute.Int("bench.worker", r.worker),
+			attribute.Int("bench.seq", r.seq),
+			attribute.Bool("bench.measured", r.measured),
+		)
+	}
+	return ctx, span
+}
+
+// endSpan завершает span итерации с её ошибкой
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+// slowCalls — самые медленные вызовы по убыванию задержки, не больше SlowestTraces
+type slowCalls []TracedCall
+
+func (s *slowCalls) add(c TracedCall) {
+	if len(*s) == SlowestTraces && c.Latency <= (*s)[len(*s)-1].Latency {
+		return
+	}
+	i := sort.Search(len(*s), func(i int) bool { return (*s)[i].Latency < c.Latency })
+	if len(*s) < SlowestTraces {
+		*s = append(*s, TracedCall{})
+	}
+	copy((*s)[i+1:], (*s)[i:])
+	(*s)[i] = c
+}
+
+func (s *slowCalls) merge(o []TracedCall) {
+	for _, c := range o {
+		s.add(c)
+	}
+}
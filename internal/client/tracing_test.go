@@ -0,0 +1,72 @@
+package client
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// calls — вызовы с задержками ms миллисекунд, trace ID — номер по порядку
+func calls(ms ...int) []TracedCall {
+	out := make([]TracedCall, len(ms))
+	for i, v := range ms {
+		out[i] = TracedCall{TraceID: strconv.Itoa(i), Latency: time.Duration(v) * time.Millisecond}
+	}
+	return out
+}
+
+func TestSlowCalls(t *testing.T) {
+	shuffled := rand.Perm(50)
+	for i := range shuffled {
+		shuffled[i]++
+	}
+	tests := []struct {
+		name  string
+		calls []TracedCall
+		want  []time.Duration
+	}{
+		{"меньше лимита", calls(3, 1, 2), []time.Duration{3, 2, 1}},
+		{"самые медленные из многих", calls(shuffled...), []time.Duration{50, 49, 48, 47, 46, 45, 44, 43, 42, 41}},
+		{"по возрастанию", calls(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12), []time.Duration{12, 11, 10, 9, 8, 7, 6, 5, 4, 3}},
+		{"равные задержки", calls(5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 9), []time.Duration{9, 5, 5, 5, 5, 5, 5, 5, 5, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s slowCalls
+			s.merge(tt.calls)
+			if len(s) != len(tt.want) {
+				t.Fatalf("вызовов %d, ожидается %d", len(s), len(tt.want))
+			}
+			for i, c := range s {
+				if c.Latency != tt.want[i]*time.Millisecond {
+					t.Errorf("вызов %d: %v, ожидается %v", i, c.Latency, tt.want[i]*time.Millisecond)
+				}
+			}
+		})
+	}
+}
+
+// Объединение самых медленных по воркерам совпадает с выбором по всем вызовам
+func TestSlowCallsMerge(t *testing.T) {
+	var a, b, all slowCalls
+	for i, c := range calls(rand.Perm(40)...) {
+		if i%2 == 0 {
+			a.add(c)
+		} else {
+			b.add(c)
+		}
+		all.add(c)
+	}
+	var merged slowCalls
+	merged.merge(a)
+	merged.merge(b)
+	for i := range all {
+		if merged[i] != all[i] {
+			t.Errorf("вызов %d: %+v, ожидается %+v", i, merged[i], all[i])
+		}
+	}
+	if got := formatTraces(merged, slowestInText); got != formatTraces(all[:3], 10) {
+		t.Errorf("formatTraces = %q", got)
+	}
+}
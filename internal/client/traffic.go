@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/stats"
+)
+
+// Traffic — байты сообщений gRPC за прогон. На проводе — после сжатия,
+// с 5-байтным префиксом сообщения gRPC, но без заголовков HTTP/2; raw —
+// сериализованные сообщения до сжатия.
+type Traffic struct {
+	SentWire     int64 `json:"sent_wire_bytes"`
+	SentRaw      int64 `json:"sent_bytes"`
+	ReceivedWire int64 `json:"received_wire_bytes"`
+	ReceivedRaw  int64 `json:"received_bytes"`
+}
+
+func (t Traffic) sub(o Traffic) Traffic {
+	return Traffic{
+		SentWire:     t.SentWire - o.SentWire,
+		SentRaw:      t.SentRaw - o.SentRaw,
+		ReceivedWire: t.ReceivedWire - o.ReceivedWire,
+		ReceivedRaw:  t.ReceivedRaw - o.ReceivedRaw,
+	}
+}
+
+func (t Traffic) add(o Traffic) Traffic {
+	return Traffic{
+		SentWire:     t.SentWire + o.SentWire,
+		SentRaw:      t.SentRaw + o.SentRaw,
+		ReceivedWire: t.ReceivedWire + o.ReceivedWire,
+		ReceivedRaw:  t.ReceivedRaw + o.ReceivedRaw,
+	}
+}
+
+// traffic считает байты сообщений по полным именам методов
+// для всех соединений, подключённых через StatsHandler
+var traffic trafficHandler
+
+// StatsHandler — обработчик статистики gRPC, который считает трафик
+// вызовов для Result.Traffic. Подключается к соединению через
+// grpc.WithStatsHandler; без него трафик в результаты не попадает.
+func StatsHandler() stats.Handler { return &traffic }
+
+type trafficHandler struct {
+	methods sync.Map // полное имя метода → *trafficCounter
+}
+
+type trafficCounter struct {
+	sentWire, sentRaw, receivedWire, receivedRaw atomic.Int64
+}
+
+type trafficKey struct{}
+
+func (h *trafficHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	c, _ := h.methods.LoadOrStore(info.FullMethodName, &trafficCounter{})
+	return context.WithValue(ctx, trafficKey{}, c)
+}
+
+func (h *trafficHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	c, ok := ctx.Value(trafficKey{}).(*trafficCounter)
+	if !ok {
+		return
+	}
+	switch p := s.(type) {
+	case *stats.OutPayload:
+		c.sentWire.Add(int64(p.WireLength))
+		c.sentRaw.Add(int64(p.Length))
+	case *stats.InPayload:
+		c.receivedWire.Add(int64(p.WireLength))
+		c.receivedRaw.Add(int64(p.Length))
+	}
+}
+
+func (h *trafficHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *trafficHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+// snapshot — трафик метода с начала работы; пустой method — всех методов
+func (h *trafficHandler) snapshot(method string) Traffic {
+	var t Traffic
+	h.methods.Range(func(k, v any) bool {
+		if method != "" && k != method {
+			return true
+		}
+		c := v.(*trafficCounter)
+		t = t.add(Traffic{
+			SentWire:     c.sentWire.Load(),
+			SentRaw:      c.sentRaw.Load(),
+			ReceivedWire: c.receivedWire.Load(),
+			ReceivedRaw:  c.receivedRaw.Load(),
+		})
+		return true
+	})
+	return t
+}
+
+// usage — отметка потребления ресурсов клиентом в начале прогона.
+// Трафик и процессорное время считаются за весь прогон, включая
+// разогрев и остывание, и по всему процессу, поэтому параллельные
+// прогоны в одном процессе их смешивают.
+type usage struct {
+	cpu     time.Duration
+	traffic map[string]Traffic
+}
+
+func startUsage(methods ...string) usage {
+	u := usage{cpu: processCPUTime(), traffic: map[string]Traffic{}}
+	for _, m := range methods {
+		u.traffic[m] = traffic.snapshot(m)
+	}
+	return u
+}
+
+// cpuTime — процессорное время клиента с начала прогона
+func (u usage) cpuTime() time.Duration {
+	return processCPUTime() - u.cpu
+}
+
+// trafficOf — трафик метода с начала прогона; nil, если трафик
+// не считается или метод не отмечен в startUsage
+func (u usage) trafficOf(method string) *Traffic {
+	start, ok := u.traffic[method]
+	if !ok {
+		return nil
+	}
+	t := traffic.snapshot(method).sub(start)
+	if t == (Traffic{}) {
+		return nil
+	}
+	return &t
+}
+
+// perCall — трафик в среднем на вызов: трафик считается за весь прогон,
+// поэтому делится на все вызовы, включая разогрев и остывание
+func (t Traffic) perCall(calls int64) (sent, received float64) {
+	if calls <= 0 {
+		return 0, 0
+	}
+	return float64(t.SentWire) / float64(calls), float64(t.ReceivedWire) / float64(calls)
+}
+
+// ratio — во сколько раз сжатие уменьшило сообщения; 0 — сообщений не было
+func ratio(raw, wire int64) float64 {
+	if wire == 0 {
+		return 0
+	}
+	return float64(raw) / float64(wire)
+}
+
+// humanBytes выводит объём с двоичным суффиксом: "512B", "1.5KiB", "12.0MiB"
+func humanBytes(v float64) string {
+	switch {
+	case v >= 1<<30:
+		return fmt.Sprintf("%.1fGiB", v/(1<<30))
+	case v >= 1<<20:
+		return fmt.Sprintf("%.1fMiB", v/(1<<20))
+	case v >= 1<<10:
+		return fmt.Sprintf("%.1fKiB", v/(1<<10))
+	}
+	return fmt.Sprintf("%.0fB", v)
+}
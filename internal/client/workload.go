@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Workload — нагрузка, которую подаёт RunWorkload. Runner берёт на себя
+// воркеры, темп и фазы прогона, подсчёт ошибок, гистограммы и отчёт;
+// Workload отвечает только за один вызов.
+//
+// Setup вызывается один раз до начала нагрузки, Teardown — после неё,
+// если Setup прошёл успешно. Execute выполняет одну итерацию и вызывается
+// из нескольких воркеров параллельно; состояние воркера можно хранить
+// по it.Worker (от 0 до Concurrency-1). Задержка итерации считается
+// runner'ом от запланированного времени отправки до возврата из Execute
+// за вычетом времени, отданного ExcludeFromLatency.
+type Workload interface {
+	Name() string   // имя результата, например "UnaryPing"
+	Method() string // полное имя gRPC метода; пусто, если нагрузка не один RPC
+	Setup(ctx context.Context) error
+	Execute(ctx context.Context, it Iteration) error
+	Teardown(ctx context.Context) error
+}
+
+// MetricsWorkload — нагрузка с дополнительными распределениями времени
+// помимо задержки итерации (например, установка потока). После прогона
+// их сводки попадают в Result.Metrics под теми же именами.
+type MetricsWorkload interface {
+	Workload
+	Metrics() map[string]*Histogram
+}
+
+// ReportWorkload — нагрузка, которая дополняет результат своими данными,
+// например статистикой сообщений серверных потоков. Report вызывается
+// после прогона, когда счётчики и задержки результата уже заполнены.
+type ReportWorkload interface {
+	Workload
+	Report(res *Result)
+}
+
+// MetricSet — именованные гистограммы, в которые можно писать из воркеров
+// параллельно. Нулевое значение готово к использованию. Значения итераций
+// разогрева и остывания, как и их задержки, в результат не попадают:
+// нагрузка пишет их только при it.Measured.
+type MetricSet struct {
+	mu    sync.Mutex
+	hists map[string]*Histogram
+}
+
+// Record добавляет значение d в гистограмму name
+func (m *MetricSet) Record(name string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.hists == nil {
+		m.hists = map[string]*Histogram{}
+	}
+	h, ok := m.hists[name]
+	if !ok {
+		h = NewHistogram()
+		m.hists[name] = h
+	}
+	h.Record(d)
+}
+
+// Histograms возвращает копии всех гистограмм
+func (m *MetricSet) Histograms() map[string]*Histogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]*Histogram, len(m.hists))
+	for name, h := range m.hists {
+		c := NewHistogram()
+		c.Merge(h)
+		out[name] = c
+	}
+	return out
+}
+
+// Iteration — одна итерация нагрузки, выданная воркеру
+type Iteration struct {
+	Worker   int  // номер воркера
+	Seq      int  // сквозной номер итерации в прогоне
+	Measured bool // false — итерация разогрева или остывания: её метрики в MetricSet не пишутся
+}
+
+// FuncWorkload — нагрузка из одной функции, без подготовки и завершения
+type FuncWorkload struct {
+	Label      string
+	FullMethod string
+	Fn         func(ctx context.Context, it Iteration) error
+}
+
+func (w FuncWorkload) Name() string                                    { return w.Label }
+func (w FuncWorkload) Method() string                                  { return w.FullMethod }
+func (w FuncWorkload) Setup(context.Context) error                     { return nil }
+func (w FuncWorkload) Execute(ctx context.Context, it Iteration) error { return w.Fn(ctx, it) }
+func (w FuncWorkload) Teardown(context.Context) error                  { return nil }
+
+// RunWorkload прогоняет нагрузку w с параметрами opts и собирает результат
+func RunWorkload(w Workload, opts LoadOptions) (*Result, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if err := w.Setup(context.Background()); err != nil {
+		return nil, fmt.Errorf("%s: подготовка: %v", w.Name(), err)
+	}
+	stats := newWorkerStats(w.Name(), opts)
+
+	usage := startUsage(w.Method())
+	started := time.Now()
+	run := runLoad(opts, func(r request) {
+		sc, err := execute(w, opts, &r)
+		stats.of(r).record(r, err, sc)
+	})
+	res := newResult(w.Name(), w.Method(), opts, started, run, stats)
+	res.CPUTime, res.Traffic = usage.cpuTime(), usage.trafficOf(w.Method())
+	res.Params.Timeout = opts.timeout(w)
+	finishResult(w, res)
+
+	if err := w.Teardown(context.Background()); err != nil {
+		return res, fmt.Errorf("%s: завершение: %v", w.Name(), err)
+	}
+	return res, nil
+}
+
+// finishResult добавляет в результат дополнительные метрики и данные
+// нагрузки, если она их собирает
+func finishResult(w Workload, res *Result) {
+	if mw, ok := w.(MetricsWorkload); ok {
+		if hists := mw.Metrics(); len(hists) > 0 {
+			res.metricHists = hists
+			res.Metrics = make(map[string]LatencySummary, len(hists))
+			for name, h := range hists {
+				res.Metrics[name] = Summarize(h)
+			}
+		}
+	}
+	if rw, ok := w.(ReportWorkload); ok {
+		rw.Report(res)
+	}
+}
+
+// execute выполняет одну итерацию с таймаутом нагрузки из opts
+// в span'е трассировки и возвращает его контекст. Время, которое
+// нагрузка исключила из задержки, записывается в r.excluded.
+func execute(w Workload, opts LoadOptions, r *request) (trace.SpanContext, error) {
+	ctx, cancel := callContext(opts.timeout(w))
+	defer cancel()
+	ctx, span := startSpan(ctx, w, *r)
+	ctx = context.WithValue(ctx, excludedKey{}, &r.excluded)
+	err := w.Execute(ctx, Iteration{Worker: r.worker, Seq: r.seq, Measured: r.measured})
+	endSpan(span, err)
+	return span.SpanContext(), err
+}
+
+type excludedKey struct{}
+
+// ExcludeFromLatency вычитает d из задержки итерации, которую выполняет
+// Execute с контекстом ctx. Так нагрузка убирает из задержки подготовку,
+// которая учитывается своей метрикой, например открытие потока. Вызывать
+// из горутины Execute; вне RunWorkload и RunMix ничего не делает.
+func ExcludeFromLatency(ctx context.Context, d time.Duration) {
+	if excluded, ok := ctx.Value(excludedKey{}).(*time.Duration); ok {
+		*excluded += d
+	}
+}
@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// echoServer сразу отвечает на все RPC сервиса бенчмарка
+type echoServer struct {
+	pb.UnimplementedBenchmarkServiceServer
+}
+
+func (echoServer) Ping(_ context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
+	return &pb.PingResponse{Message: req.Message}, nil
+}
+
+func (echoServer) StreamPing(stream pb.BenchmarkService_StreamPingServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pb.PingResponse{Message: req.Message}); err != nil {
+			return err
+		}
+	}
+}
+
+func (echoServer) AggregatePing(stream pb.BenchmarkService_AggregatePingServer) error {
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			return stream.SendAndClose(&pb.PingResponse{})
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (echoServer) PushNotifications(req *pb.PingRequest, stream pb.BenchmarkService_PushNotificationsServer) error {
+	for i := 0; i < 3; i++ {
+		if err := stream.Send(&pb.PingResponse{Message: req.Message}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// benchConn поднимает в памяти сервер srv и возвращает соединение с ним
+func benchConn(t *testing.T, srv pb.BenchmarkServiceServer, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterBenchmarkServiceServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	opts = append(opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Итерации разогрева и остывания приходят в Execute с Measured = false,
+// а измеряемые — с true, ровно столько, сколько запросов в результате
+func TestRunWorkloadMeasuredIterations(t *testing.T) {
+	var measured, discarded atomic.Int64
+	w := FuncWorkload{Label: "f", Fn: func(_ context.Context, it Iteration) error {
+		if it.Measured {
+			measured.Add(1)
+		} else {
+			discarded.Add(1)
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	}}
+	opts := LoadOptions{Scenario: ScenarioConstant, Concurrency: 2, WarmUp: 30 * time.Millisecond,
+		Duration: 60 * time.Millisecond, CoolDown: 30 * time.Millisecond}
+	res, err := RunWorkload(w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if measured.Load() != res.Requests || discarded.Load() != res.Discarded || res.Discarded == 0 {
+		t.Errorf("Measured: %d, разогрев и остывание: %d; в результате %d и %d",
+			measured.Load(), discarded.Load(), res.Requests, res.Discarded)
+	}
+}
+
+// Дополнительные метрики нагрузок пишутся только для измеряемых итераций
+func TestWorkloadMetricsSkipUnmeasured(t *testing.T) {
+	conn := benchConn(t, echoServer{})
+	c := pb.NewBenchmarkServiceClient(conn)
+	push := pb.File_proto_benchmark_proto.Services().ByName("BenchmarkService").Methods().ByName("PushNotifications")
+	tmpl, err := ParseRequestTemplate("{}", push.Input())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		w    Workload
+		want []string
+	}{
+		{"AggregatePing", AggregatePingWorkload(c, Payload{}, Batch{Messages: 2}), []string{MetricFirstSend, MetricAckLatency, MetricStreamDuration}},
+		{"PushNotifications", PushNotificationsWorkload(c, Payload{}, PushStream{}), []string{MetricFirstMessage, MetricInterArrival, MetricJitter}},
+		{"generic", GenericWorkload(conn, GenericCall{Method: push, Template: tmpl}, LoadOptions{}), []string{MetricFirstMessage}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tt.w.Setup(ctx); err != nil {
+				t.Fatal(err)
+			}
+			defer tt.w.Teardown(ctx)
+			metrics := func() map[string]*Histogram { return tt.w.(MetricsWorkload).Metrics() }
+
+			if err := tt.w.Execute(ctx, Iteration{Measured: false}); err != nil {
+				t.Fatal(err)
+			}
+			for name, h := range metrics() {
+				if h.Count() > 0 {
+					t.Errorf("%s: %d значений разогрева", name, h.Count())
+				}
+			}
+			if err := tt.w.Execute(ctx, Iteration{Seq: 1, Measured: true}); err != nil {
+				t.Fatal(err)
+			}
+			got := metrics()
+			for _, name := range tt.want {
+				if h := got[name]; h == nil || h.Count() == 0 {
+					t.Errorf("%s: нет значений измеряемой итерации", name)
+				}
+			}
+		})
+	}
+}
+
+// Время, отданное ExcludeFromLatency, не входит в задержку итерации
+func TestExcludeFromLatency(t *testing.T) {
+	const prep = 50 * time.Millisecond
+	w := FuncWorkload{Label: "f", Fn: func(ctx context.Context, _ Iteration) error {
+		time.Sleep(prep)
+		ExcludeFromLatency(ctx, prep)
+		return nil
+	}}
+	res, err := RunWorkload(w, LoadOptions{Scenario: ScenarioConstant, Concurrency: 1, Requests: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if max := res.Histogram.Max(); max >= prep {
+		t.Errorf("наибольшая задержка %s, ожидается меньше %s", max, prep)
+	}
+}
+
+func TestMetricSet(t *testing.T) {
+	var m MetricSet
+	if len(m.Histograms()) != 0 {
+		t.Fatal("у нулевого MetricSet есть гистограммы")
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.Record("a", time.Millisecond)
+				if i%2 == 0 {
+					m.Record("b", time.Second)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	hists := m.Histograms()
+	if len(hists) != 2 || hists["a"].Count() != 800 || hists["b"].Count() != 400 {
+		t.Fatalf("гистограммы %v", hists)
+	}
+	// Histograms возвращает копии: запись после неё их не меняет
+	m.Record("a", time.Millisecond)
+	if hists["a"].Count() != 800 {
+		t.Error("Histograms вернул не копию")
+	}
+}
+
+// lifecycleWorkload — нагрузка с подготовкой, завершением,
+// дополнительной метрикой и своими данными в результате
+type lifecycleWorkload struct {
+	FuncWorkload
+	setupErr, teardownErr error
+	setup, teardown       atomic.Int32
+	metrics               MetricSet
+}
+
+func (w *lifecycleWorkload) Setup(context.Context) error {
+	w.setup.Add(1)
+	return w.setupErr
+}
+
+func (w *lifecycleWorkload) Execute(_ context.Context, it Iteration) error {
+	if it.Measured {
+		w.metrics.Record("stage", time.Millisecond)
+	}
+	return nil
+}
+
+func (w *lifecycleWorkload) Teardown(context.Context) error {
+	w.teardown.Add(1)
+	return w.teardownErr
+}
+
+func (w *lifecycleWorkload) Metrics() map[string]*Histogram { return w.metrics.Histograms() }
+
+func (w *lifecycleWorkload) Report(res *Result) { res.Method = "reported" }
+
+func TestRunWorkloadLifecycle(t *testing.T) {
+	opts := LoadOptions{Scenario: ScenarioConstant, Concurrency: 2, Requests: 10}
+	tests := []struct {
+		name                  string
+		setupErr, teardownErr error
+		err                   string
+		teardowns             int32
+		result                bool
+	}{
+		{"успешный прогон", nil, nil, "", 1, true},
+		{"ошибка подготовки", errors.New("no conn"), nil, "f: подготовка: no conn", 0, false},
+		{"ошибка завершения", nil, errors.New("close"), "f: завершение: close", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &lifecycleWorkload{FuncWorkload: FuncWorkload{Label: "f"}, setupErr: tt.setupErr, teardownErr: tt.teardownErr}
+			res, err := RunWorkload(w, opts)
+			if (err == nil) != (tt.err == "") || err != nil && err.Error() != tt.err {
+				t.Fatalf("ошибка %v, ожидается %q", err, tt.err)
+			}
+			if w.setup.Load() != 1 || w.teardown.Load() != tt.teardowns {
+				t.Errorf("Setup вызван %d раз, Teardown %d, ожидается 1 и %d", w.setup.Load(), w.teardown.Load(), tt.teardowns)
+			}
+			if (res != nil) != tt.result {
+				t.Fatalf("результат %v", res)
+			}
+			if res == nil {
+				return
+			}
+			if res.Metrics["stage"].Count != 10 || res.Method != "reported" {
+				t.Errorf("метрики %v, метод %q: Metrics и Report не применены", res.Metrics, res.Method)
+			}
+		})
+	}
+}
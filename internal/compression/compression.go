@@ -0,0 +1,90 @@
+// Package compression регистрирует компрессоры gRPC, которые
+// поддерживают клиент и сервер бенчмарка. Пакет подключается
+// к обоим бинарникам, чтобы сервер мог разжать запрос и ответить
+// тем же алгоритмом.
+package compression
+
+import (
+	"compress/flate"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // регистрирует "gzip"
+)
+
+// Identity — без сжатия
+const Identity = encoding.Identity
+
+// Names — алгоритмы, доступные клиенту и серверу
+var Names = []string{Identity, "gzip", "deflate"}
+
+func init() {
+	encoding.RegisterCompressor(&deflate{})
+}
+
+// Check проверяет, что алгоритм зарегистрирован
+func Check(name string) error {
+	if name == Identity || encoding.GetCompressor(name) != nil {
+		return nil
+	}
+	return fmt.Errorf("неизвестный алгоритм сжатия %q, доступны: %s", name, strings.Join(Names, ", "))
+}
+
+// deflate — сжатие DEFLATE без обёртки gzip из стандартной библиотеки.
+// Писатели и читатели переиспользуются через sync.Pool, как в gzip из grpc.
+type deflate struct {
+	writers sync.Pool
+	readers sync.Pool
+}
+
+func (c *deflate) Name() string { return "deflate" }
+
+func (c *deflate) Compress(w io.Writer) (io.WriteCloser, error) {
+	if fw, ok := c.writers.Get().(*flate.Writer); ok {
+		fw.Reset(w)
+		return &deflateWriter{Writer: fw, pool: &c.writers}, nil
+	}
+	fw, err := flate.NewWriter(w, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	return &deflateWriter{Writer: fw, pool: &c.writers}, nil
+}
+
+func (c *deflate) Decompress(r io.Reader) (io.Reader, error) {
+	fr, ok := c.readers.Get().(io.ReadCloser)
+	if !ok {
+		return &deflateReader{ReadCloser: flate.NewReader(r), pool: &c.readers}, nil
+	}
+	if err := fr.(flate.Resetter).Reset(r, nil); err != nil {
+		return nil, err
+	}
+	return &deflateReader{ReadCloser: fr, pool: &c.readers}, nil
+}
+
+type deflateWriter struct {
+	*flate.Writer
+	pool *sync.Pool
+}
+
+func (w *deflateWriter) Close() error {
+	defer w.pool.Put(w.Writer)
+	return w.Writer.Close()
+}
+
+// deflateReader возвращает читатель в пул, когда сообщение прочитано до конца
+type deflateReader struct {
+	io.ReadCloser
+	pool *sync.Pool
+}
+
+func (r *deflateReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.ReadCloser)
+	}
+	return n, err
+}
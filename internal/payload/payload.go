@@ -0,0 +1,79 @@
+// Package payload выдаёт байты полезной нагрузки сообщений бенчмарка.
+// Клиент и сервер берут их из общих буферов, поэтому при любом размере
+// сообщения payload не выделяется заново на каждый вызов.
+package payload
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+// words — словарь текстового payload. Текст из него сжимается примерно
+// как логи или JSON: в несколько раз, но не в сотни.
+var words = strings.Fields(`benchmark request response latency stream server client message
+	status error timeout deadline payload metric histogram percentile throughput
+	user id name value count total created updated true false null`)
+
+// buffer — растущий буфер байт одного вида; срезы из него только читаются
+// при сериализации, поэтому их можно отдавать горутинам параллельно
+type buffer struct {
+	mu   sync.Mutex
+	b    []byte
+	fill func(n int) []byte
+}
+
+// MaxSize — наибольший payload запроса и ответа по умолчанию. Клиент
+// отклоняет больший, сервер столько разрешает без -max-payload.
+const MaxSize = 16 << 20
+
+// messageOverhead — запас на остальные поля сообщения сверх payload
+const messageOverhead = 64 << 10
+
+// MessageSizeLimit — лимит размера сообщений gRPC, при котором проходят
+// запросы и ответы с payload до maxPayload байт; не меньше стандартных
+// 4MiB. Клиент и сервер поднимают свои лимиты по нему, поэтому они
+// сходятся при одинаковом maxPayload.
+func MessageSizeLimit(maxPayload int) int {
+	return max(4<<20, maxPayload+messageOverhead)
+}
+
+var buffers = map[pb.PayloadFill]*buffer{
+	pb.PayloadFill_PAYLOAD_RANDOM: {fill: randomBytes},
+	pb.PayloadFill_PAYLOAD_TEXT:   {fill: textBytes},
+}
+
+// Bytes возвращает n байт payload вида fill; неизвестный вид — случайные байты
+func Bytes(n int, fill pb.PayloadFill) []byte {
+	if n <= 0 {
+		return nil
+	}
+	buf, ok := buffers[fill]
+	if !ok {
+		buf = buffers[pb.PayloadFill_PAYLOAD_RANDOM]
+	}
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	if len(buf.b) < n {
+		buf.b = buf.fill(max(n, 2*len(buf.b)))
+	}
+	return buf.b[:n]
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+func textBytes(n int) []byte {
+	rng := rand.New(rand.NewSource(1))
+	b := make([]byte, 0, n+16)
+	for len(b) < n {
+		b = append(b, words[rng.Intn(len(words))]...)
+		b = append(b, ' ')
+	}
+	return b[:n]
+}
@@ -0,0 +1,32 @@
+package payload
+
+import (
+	"testing"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+func TestMessageSizeLimit(t *testing.T) {
+	for maxPayload, want := range map[int]int{0: 4 << 20, 1 << 20: 4 << 20, 4 << 20: 4<<20 + messageOverhead, MaxSize: MaxSize + messageOverhead} {
+		if got := MessageSizeLimit(maxPayload); got != want {
+			t.Errorf("MessageSizeLimit(%d) = %d, ожидается %d", maxPayload, got, want)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	for _, fill := range []pb.PayloadFill{pb.PayloadFill_PAYLOAD_RANDOM, pb.PayloadFill_PAYLOAD_TEXT, pb.PayloadFill(99)} {
+		if b := Bytes(0, fill); b != nil {
+			t.Errorf("%v: %d байт для нулевого размера", fill, len(b))
+		}
+		small, big := Bytes(100, fill), Bytes(10000, fill)
+		if len(small) != 100 || len(big) != 10000 {
+			t.Fatalf("%v: %d и %d байт, ожидается 100 и 10000", fill, len(small), len(big))
+		}
+	}
+	for _, c := range Bytes(1000, pb.PayloadFill_PAYLOAD_TEXT) {
+		if c != ' ' && (c < 'a' || c > 'z') {
+			t.Fatalf("текстовый payload содержит байт %q", c)
+		}
+	}
+}
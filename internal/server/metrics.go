@@ -35,8 +35,43 @@ var (
 	RPCResponseSizeBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "grpc_rpc_response_size_bytes", Help: "Size of gRPC responses"}, []string{"method"})
 )
 
+// --- Дедлайны клиентов ---
+var (
+	RPCDeadlineBudgetSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_rpc_deadline_budget_seconds",
+		Help:    "Time left until the client deadline when the call reached the server",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+	RPCDeadlineExpiredTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_rpc_deadline_expired_total",
+		Help: "Number of gRPC calls whose handler finished after the client deadline",
+	}, []string{"method"})
+	RPCWorkAfterDeadlineSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_rpc_work_after_deadline_seconds",
+		Help:    "Time the handler kept working after the client deadline expired",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
 func init() {
-	prometheus.MustRegister(RPCRequestsTotal, RPCLatencySeconds, RPCRequestSizeBytes, RPCResponseSizeBytes)
+	prometheus.MustRegister(RPCRequestsTotal, RPCLatencySeconds, RPCRequestSizeBytes, RPCResponseSizeBytes,
+		RPCDeadlineBudgetSeconds, RPCDeadlineExpiredTotal, RPCWorkAfterDeadlineSeconds)
+}
+
+// observeDeadline учитывает дедлайн клиента: сколько времени до него
+// оставалось в момент start и сколько обработчик работал после него.
+// Вызывается после завершения обработчика.
+func observeDeadline(ctx context.Context, method string, start time.Time) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	RPCDeadlineBudgetSeconds.WithLabelValues(method).Observe(deadline.Sub(start).Seconds())
+	if over := time.Since(deadline); over > 0 {
+		RPCDeadlineExpiredTotal.WithLabelValues(method).Inc()
+		RPCWorkAfterDeadlineSeconds.WithLabelValues(method).Observe(over.Seconds())
+		Debug("RPC %s: обработчик работал %s после дедлайна клиента", method, over)
+	}
 }
 
 // PrometheusUnaryInterceptor
@@ -49,6 +84,7 @@ func PrometheusUnaryInterceptor(ctx context.Context, req interface{}, info *grpc
 	}
 
 	resp, err := handler(ctx, req)
+	observeDeadline(ctx, info.FullMethod, start)
 
 	// размер ответа
 	if m, ok := resp.(proto.Message); ok {
@@ -74,6 +110,7 @@ func PrometheusUnaryInterceptor(ctx context.Context, req interface{}, info *grpc
 func PrometheusStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 	start := time.Now()
 	err := handler(srv, ss)
+	observeDeadline(ss.Context(), info.FullMethod, start)
 	elapsed := time.Since(start).Seconds()
 
 	status := "success"
@@ -0,0 +1,21 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/go-portfolio/go-grpc-benchmark/internal/payload"
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// responsePayload — payload ответа размера и вида из запроса req
+// в пределах лимита сервера; req может быть nil
+func (s *Server) responsePayload(req *pb.PingRequest) ([]byte, error) {
+	size := req.GetResponseSize()
+	if int64(size) > int64(s.maxPayload) {
+		err := fmt.Errorf("response_size %d больше лимита сервера %d", size, s.maxPayload)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return payload.Bytes(int(size), req.GetResponseFill()), nil
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+	"google.golang.org/grpc/status"
+)
+
+// StreamLimits — ограничения параметров потока PushNotifications,
+// которые клиент задаёт в запросе
+type StreamLimits struct {
+	MaxMessages    int           // сообщений в потоке
+	MaxInterval    time.Duration // интервал между сообщениями
+	MaxPayloadSize int           // размер payload сообщения потока в байтах
+}
+
+// DefaultStreamLimits — лимиты сервера по умолчанию
+var DefaultStreamLimits = StreamLimits{
+	MaxMessages:    100000,
+	MaxInterval:    10 * time.Second,
+	MaxPayloadSize: 1 << 20,
+}
+
+// check проверяет параметры потока на соответствие лимитам
+func (l StreamLimits) check(p *pb.StreamParams) error {
+	if p.Count == 0 {
+		return errors.New("count должен быть больше нуля")
+	}
+	if int64(p.Count) > int64(l.MaxMessages) {
+		return fmt.Errorf("count %d больше лимита сервера %d", p.Count, l.MaxMessages)
+	}
+	if p.IntervalUs > uint64(l.MaxInterval/time.Microsecond) {
+		return fmt.Errorf("interval %dus больше лимита сервера %s", p.IntervalUs, l.MaxInterval)
+	}
+	if int64(p.PayloadSize) > int64(l.MaxPayloadSize) {
+		return fmt.Errorf("payload_size %d больше лимита сервера %d", p.PayloadSize, l.MaxPayloadSize)
+	}
+	if _, ok := pb.IntervalDistribution_name[int32(p.Distribution)]; !ok {
+		return fmt.Errorf("неизвестное распределение интервала %d", p.Distribution)
+	}
+	return nil
+}
+
+// pushSchedule — число сообщений потока и интервалы перед ними
+type pushSchedule struct {
+	count       int
+	interval    time.Duration
+	dist        pb.IntervalDistribution
+	payloadSize int
+}
+
+// defaultPushSchedule — поток без параметров в запросе: 5 сообщений
+// с интервалом 50-100ms
+var defaultPushSchedule = pushSchedule{count: 5, interval: 75 * time.Millisecond, dist: -1}
+
+func newPushSchedule(p *pb.StreamParams) pushSchedule {
+	return pushSchedule{
+		count:       int(p.Count),
+		interval:    time.Duration(p.IntervalUs) * time.Microsecond,
+		dist:        p.Distribution,
+		payloadSize: int(p.PayloadSize),
+	}
+}
+
+// next возвращает интервал перед очередным сообщением
+func (s pushSchedule) next() time.Duration {
+	switch s.dist {
+	case pb.IntervalDistribution_INTERVAL_FIXED:
+		return s.interval
+	case pb.IntervalDistribution_INTERVAL_UNIFORM:
+		return time.Duration(rand.Float64() * 2 * float64(s.interval))
+	case pb.IntervalDistribution_INTERVAL_EXPONENTIAL:
+		return time.Duration(rand.ExpFloat64() * float64(s.interval))
+	}
+	return time.Duration(50+rand.Intn(50)) * time.Millisecond
+}
+
+// pause ждёт d или отмены ctx
+func pause(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
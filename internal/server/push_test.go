@@ -0,0 +1,64 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+)
+
+func TestStreamLimitsCheck(t *testing.T) {
+	limits := StreamLimits{MaxMessages: 10, MaxInterval: time.Second, MaxPayloadSize: 1024}
+	tests := []struct {
+		name   string
+		params *pb.StreamParams
+		err    string // пусто — параметры допустимы
+	}{
+		{"в пределах лимитов", &pb.StreamParams{Count: 10, IntervalUs: 1e6, PayloadSize: 1024, Distribution: pb.IntervalDistribution_INTERVAL_EXPONENTIAL}, ""},
+		{"без сообщений", &pb.StreamParams{}, "count"},
+		{"много сообщений", &pb.StreamParams{Count: 11}, "count 11"},
+		{"длинный интервал", &pb.StreamParams{Count: 1, IntervalUs: 1e6 + 1}, "interval"},
+		{"большой payload", &pb.StreamParams{Count: 1, PayloadSize: 1025}, "payload_size"},
+		{"неизвестное распределение", &pb.StreamParams{Count: 1, Distribution: 42}, "распределение"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.check(tt.params)
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("check = %v, ожидается %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPushScheduleNext(t *testing.T) {
+	const n = 10000
+	interval := 10 * time.Millisecond
+	tests := []struct {
+		name     string
+		schedule pushSchedule
+		min, max time.Duration // границы каждого интервала
+		mean     time.Duration // ожидаемое среднее
+	}{
+		{"fixed", newPushSchedule(&pb.StreamParams{Count: 1, IntervalUs: 10000}), interval, interval, interval},
+		{"uniform", newPushSchedule(&pb.StreamParams{Count: 1, IntervalUs: 10000, Distribution: pb.IntervalDistribution_INTERVAL_UNIFORM}), 0, 2 * interval, interval},
+		{"exponential", newPushSchedule(&pb.StreamParams{Count: 1, IntervalUs: 10000, Distribution: pb.IntervalDistribution_INTERVAL_EXPONENTIAL}), 0, time.Hour, interval},
+		{"по умолчанию", defaultPushSchedule, 50 * time.Millisecond, 99 * time.Millisecond, 75 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sum time.Duration
+			for i := 0; i < n; i++ {
+				d := tt.schedule.next()
+				if d < tt.min || d > tt.max {
+					t.Fatalf("интервал %v вне [%v, %v]", d, tt.min, tt.max)
+				}
+				sum += d
+			}
+			if mean := sum / n; mean < tt.mean*9/10 || mean > tt.mean*11/10 {
+				t.Errorf("средний интервал %v, ожидается около %v", mean, tt.mean)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-portfolio/go-grpc-benchmark/internal/payload"
 	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
 )
 
@@ -16,16 +17,33 @@ type Server struct {
 	reqCount  int
 	totalTime time.Duration
 	failCount int
+	limits    StreamLimits
+
+	maxPayload int // наибольший response_size
 }
 
 // Конструктор сервера с debug и verbose флагами
 func NewServer(debug, verbose bool) *Server {
 	return &Server{
-		debug:   debug,
-		verbose: verbose,
+		debug:      debug,
+		verbose:    verbose,
+		limits:     DefaultStreamLimits,
+		maxPayload: payload.MaxSize,
 	}
 }
 
+// SetStreamLimits задаёт лимиты параметров потока PushNotifications
+func (s *Server) SetStreamLimits(l StreamLimits) {
+	s.limits = l
+}
+
+// SetMaxPayloadSize задаёт наибольший response_size, который может
+// запросить клиент. Лимиты размера сообщений gRPC сервера должны
+// вмещать такой payload, см. payload.MessageSizeLimit.
+func (s *Server) SetMaxPayloadSize(n int) {
+	s.maxPayload = n
+}
+
 // Вспомогательная функция для вывода debug-логов
 func (s *Server) logDebug(format string, v ...interface{}) {
 	if s.debug {
@@ -2,21 +2,24 @@ package server
 
 import (
 	"io"
-	"math/rand"
 	"strconv"
 	"time"
 
+	"github.com/go-portfolio/go-grpc-benchmark/internal/payload"
 	pb "github.com/go-portfolio/go-grpc-benchmark/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Bidirectional Streaming RPC: StreamPing
 func (s *Server) StreamPing(stream pb.BenchmarkService_StreamPingServer) error {
 	for {
 		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
-			if err != io.EOF {
-				Error("StreamPing Recv error: %v", err)
-			}
+			Error("StreamPing Recv error: %v", err)
 			return err
 		}
 
@@ -33,7 +36,11 @@ func (s *Server) StreamPing(stream pb.BenchmarkService_StreamPingServer) error {
 			s.mu.Unlock()
 		}
 
-		if sendErr := stream.Send(&pb.PingResponse{Message: "echo: " + msg}); sendErr != nil {
+		payload, err := s.responsePayload(req)
+		if err != nil {
+			return err
+		}
+		if sendErr := stream.Send(&pb.PingResponse{Message: "echo: " + msg, Payload: payload}); sendErr != nil {
 			Error("StreamPing send error: %v", sendErr)
 			return sendErr
 		}
@@ -41,12 +48,24 @@ func (s *Server) StreamPing(stream pb.BenchmarkService_StreamPingServer) error {
 	}
 }
 
-// Server Streaming RPC: PushNotifications
+// Server Streaming RPC: PushNotifications. Число сообщений, интервал
+// и размер задаются в req.Stream в пределах лимитов сервера.
 func (s *Server) PushNotifications(req *pb.PingRequest, stream pb.BenchmarkService_PushNotificationsServer) error {
-	for i := 1; i <= 5; i++ {
-		time.Sleep(time.Duration(50+rand.Intn(50)) * time.Millisecond)
+	schedule := defaultPushSchedule
+	if p := req.GetStream(); p != nil {
+		if err := s.limits.check(p); err != nil {
+			Error("PushNotifications: %v", err)
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		schedule = newPushSchedule(p)
+	}
+
+	for i := 1; i <= schedule.count; i++ {
+		if err := pause(stream.Context(), schedule.next()); err != nil {
+			return err
+		}
 		msg := req.Message + " #" + strconv.Itoa(i)
-		if err := stream.Send(&pb.PingResponse{Message: msg}); err != nil {
+		if err := stream.Send(&pb.PingResponse{Message: msg, Payload: payload.Bytes(schedule.payloadSize, req.ResponseFill)}); err != nil {
 			Error("PushNotifications send error: %v", err)
 			return err
 		}
@@ -60,13 +79,18 @@ func (s *Server) PushNotifications(req *pb.PingRequest, stream pb.BenchmarkServi
 func (s *Server) AggregatePing(stream pb.BenchmarkService_AggregatePingServer) error {
 	count := 0
 	messages := ""
+	var last *pb.PingRequest // размер и вид payload ответа — из последнего сообщения потока
 	for {
 		req, err := stream.Recv()
 		if err != nil {
 			if err == io.EOF {
+				payload, err := s.responsePayload(last)
+				if err != nil {
+					return err
+				}
 				response := "Aggregated " + strconv.Itoa(count) + " messages: " + messages
 				Debug("AggregatePing done: %s", response)
-				return stream.SendAndClose(&pb.PingResponse{Message: response})
+				return stream.SendAndClose(&pb.PingResponse{Message: response, Payload: payload})
 			}
 			Error("AggregatePing recv error: %v", err)
 			return err
@@ -74,6 +98,7 @@ func (s *Server) AggregatePing(stream pb.BenchmarkService_AggregatePingServer) e
 
 		count++
 		messages += req.Message + " | "
+		last = req
 
 		delay, procErr := SimulateProcessing()
 		time.Sleep(delay)
@@ -21,8 +21,12 @@ func (s *Server) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingRespons
 	s.totalTime += elapsed
 	s.mu.Unlock()
 
+	payload, err := s.responsePayload(req)
+	if err != nil {
+		return nil, err
+	}
 	s.logDebug("Ping processed in %v", elapsed)
-	return &pb.PingResponse{Message: req.Message}, nil
+	return &pb.PingResponse{Message: req.Message, Payload: payload}, nil
 }
 
 // Unary RPC: Stats
@@ -21,9 +21,108 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type PayloadFill int32
+
+const (
+	PayloadFill_PAYLOAD_RANDOM PayloadFill = 0
+	PayloadFill_PAYLOAD_TEXT   PayloadFill = 1
+)
+
+// Enum value maps for PayloadFill.
+var (
+	PayloadFill_name = map[int32]string{
+		0: "PAYLOAD_RANDOM",
+		1: "PAYLOAD_TEXT",
+	}
+	PayloadFill_value = map[string]int32{
+		"PAYLOAD_RANDOM": 0,
+		"PAYLOAD_TEXT":   1,
+	}
+)
+
+func (x PayloadFill) Enum() *PayloadFill {
+	p := new(PayloadFill)
+	*p = x
+	return p
+}
+
+func (x PayloadFill) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PayloadFill) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_benchmark_proto_enumTypes[0].Descriptor()
+}
+
+func (PayloadFill) Type() protoreflect.EnumType {
+	return &file_proto_benchmark_proto_enumTypes[0]
+}
+
+func (x PayloadFill) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PayloadFill.Descriptor instead.
+func (PayloadFill) EnumDescriptor() ([]byte, []int) {
+	return file_proto_benchmark_proto_rawDescGZIP(), []int{0}
+}
+
+type IntervalDistribution int32
+
+const (
+	IntervalDistribution_INTERVAL_FIXED       IntervalDistribution = 0
+	IntervalDistribution_INTERVAL_UNIFORM     IntervalDistribution = 1
+	IntervalDistribution_INTERVAL_EXPONENTIAL IntervalDistribution = 2
+)
+
+// Enum value maps for IntervalDistribution.
+var (
+	IntervalDistribution_name = map[int32]string{
+		0: "INTERVAL_FIXED",
+		1: "INTERVAL_UNIFORM",
+		2: "INTERVAL_EXPONENTIAL",
+	}
+	IntervalDistribution_value = map[string]int32{
+		"INTERVAL_FIXED":       0,
+		"INTERVAL_UNIFORM":     1,
+		"INTERVAL_EXPONENTIAL": 2,
+	}
+)
+
+func (x IntervalDistribution) Enum() *IntervalDistribution {
+	p := new(IntervalDistribution)
+	*p = x
+	return p
+}
+
+func (x IntervalDistribution) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (IntervalDistribution) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_benchmark_proto_enumTypes[1].Descriptor()
+}
+
+func (IntervalDistribution) Type() protoreflect.EnumType {
+	return &file_proto_benchmark_proto_enumTypes[1]
+}
+
+func (x IntervalDistribution) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use IntervalDistribution.Descriptor instead.
+func (IntervalDistribution) EnumDescriptor() ([]byte, []int) {
+	return file_proto_benchmark_proto_rawDescGZIP(), []int{1}
+}
+
 type PingRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Stream        *StreamParams          `protobuf:"bytes,2,opt,name=stream,proto3" json:"stream,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	ResponseSize  uint32                 `protobuf:"varint,4,opt,name=response_size,json=responseSize,proto3" json:"response_size,omitempty"`
+	ResponseFill  PayloadFill            `protobuf:"varint,5,opt,name=response_fill,json=responseFill,proto3,enum=benchmark.PayloadFill" json:"response_fill,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -65,16 +164,113 @@ func (x *PingRequest) GetMessage() string {
 	return ""
 }
 
+func (x *PingRequest) GetStream() *StreamParams {
+	if x != nil {
+		return x.Stream
+	}
+	return nil
+}
+
+func (x *PingRequest) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *PingRequest) GetResponseSize() uint32 {
+	if x != nil {
+		return x.ResponseSize
+	}
+	return 0
+}
+
+func (x *PingRequest) GetResponseFill() PayloadFill {
+	if x != nil {
+		return x.ResponseFill
+	}
+	return PayloadFill_PAYLOAD_RANDOM
+}
+
+type StreamParams struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         uint32                 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	IntervalUs    uint64                 `protobuf:"varint,2,opt,name=interval_us,json=intervalUs,proto3" json:"interval_us,omitempty"`
+	Distribution  IntervalDistribution   `protobuf:"varint,3,opt,name=distribution,proto3,enum=benchmark.IntervalDistribution" json:"distribution,omitempty"`
+	PayloadSize   uint32                 `protobuf:"varint,4,opt,name=payload_size,json=payloadSize,proto3" json:"payload_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamParams) Reset() {
+	*x = StreamParams{}
+	mi := &file_proto_benchmark_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamParams) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamParams) ProtoMessage() {}
+
+func (x *StreamParams) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_benchmark_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamParams.ProtoReflect.Descriptor instead.
+func (*StreamParams) Descriptor() ([]byte, []int) {
+	return file_proto_benchmark_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StreamParams) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *StreamParams) GetIntervalUs() uint64 {
+	if x != nil {
+		return x.IntervalUs
+	}
+	return 0
+}
+
+func (x *StreamParams) GetDistribution() IntervalDistribution {
+	if x != nil {
+		return x.Distribution
+	}
+	return IntervalDistribution_INTERVAL_FIXED
+}
+
+func (x *StreamParams) GetPayloadSize() uint32 {
+	if x != nil {
+		return x.PayloadSize
+	}
+	return 0
+}
+
 type PingResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Payload       []byte                 `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *PingResponse) Reset() {
 	*x = PingResponse{}
-	mi := &file_proto_benchmark_proto_msgTypes[1]
+	mi := &file_proto_benchmark_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -86,7 +282,7 @@ func (x *PingResponse) String() string {
 func (*PingResponse) ProtoMessage() {}
 
 func (x *PingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_benchmark_proto_msgTypes[1]
+	mi := &file_proto_benchmark_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -99,7 +295,7 @@ func (x *PingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return file_proto_benchmark_proto_rawDescGZIP(), []int{1}
+	return file_proto_benchmark_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *PingResponse) GetMessage() string {
@@ -109,6 +305,13 @@ func (x *PingResponse) GetMessage() string {
 	return ""
 }
 
+func (x *PingResponse) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
 type StatsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -117,7 +320,7 @@ type StatsRequest struct {
 
 func (x *StatsRequest) Reset() {
 	*x = StatsRequest{}
-	mi := &file_proto_benchmark_proto_msgTypes[2]
+	mi := &file_proto_benchmark_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -129,7 +332,7 @@ func (x *StatsRequest) String() string {
 func (*StatsRequest) ProtoMessage() {}
 
 func (x *StatsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_benchmark_proto_msgTypes[2]
+	mi := &file_proto_benchmark_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -142,7 +345,7 @@ func (x *StatsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
 func (*StatsRequest) Descriptor() ([]byte, []int) {
-	return file_proto_benchmark_proto_rawDescGZIP(), []int{2}
+	return file_proto_benchmark_proto_rawDescGZIP(), []int{3}
 }
 
 type StatsResponse struct {
@@ -155,7 +358,7 @@ type StatsResponse struct {
 
 func (x *StatsResponse) Reset() {
 	*x = StatsResponse{}
-	mi := &file_proto_benchmark_proto_msgTypes[3]
+	mi := &file_proto_benchmark_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -167,7 +370,7 @@ func (x *StatsResponse) String() string {
 func (*StatsResponse) ProtoMessage() {}
 
 func (x *StatsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_benchmark_proto_msgTypes[3]
+	mi := &file_proto_benchmark_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -180,7 +383,7 @@ func (x *StatsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
 func (*StatsResponse) Descriptor() ([]byte, []int) {
-	return file_proto_benchmark_proto_rawDescGZIP(), []int{3}
+	return file_proto_benchmark_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *StatsResponse) GetTotalRequests() int32 {
@@ -201,15 +404,33 @@ var File_proto_benchmark_proto protoreflect.FileDescriptor
 
 const file_proto_benchmark_proto_rawDesc = "" +
 	"\n" +
-	"\x15proto/benchmark.proto\x12\tbenchmark\"'\n" +
+	"\x15proto/benchmark.proto\x12\tbenchmark\"\xd4\x01\n" +
 	"\vPingRequest\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\"(\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12/\n" +
+	"\x06stream\x18\x02 \x01(\v2\x17.benchmark.StreamParamsR\x06stream\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\x12#\n" +
+	"\rresponse_size\x18\x04 \x01(\rR\fresponseSize\x12;\n" +
+	"\rresponse_fill\x18\x05 \x01(\x0e2\x16.benchmark.PayloadFillR\fresponseFill\"\xad\x01\n" +
+	"\fStreamParams\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\rR\x05count\x12\x1f\n" +
+	"\vinterval_us\x18\x02 \x01(\x04R\n" +
+	"intervalUs\x12C\n" +
+	"\fdistribution\x18\x03 \x01(\x0e2\x1f.benchmark.IntervalDistributionR\fdistribution\x12!\n" +
+	"\fpayload_size\x18\x04 \x01(\rR\vpayloadSize\"B\n" +
 	"\fPingResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\"\x0e\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x18\n" +
+	"\apayload\x18\x02 \x01(\fR\apayload\"\x0e\n" +
 	"\fStatsRequest\"[\n" +
 	"\rStatsResponse\x12$\n" +
 	"\rtotalRequests\x18\x01 \x01(\x05R\rtotalRequests\x12$\n" +
-	"\ravgLatencySec\x18\x02 \x01(\x01R\ravgLatencySec2\xd6\x02\n" +
+	"\ravgLatencySec\x18\x02 \x01(\x01R\ravgLatencySec*3\n" +
+	"\vPayloadFill\x12\x12\n" +
+	"\x0ePAYLOAD_RANDOM\x10\x00\x12\x10\n" +
+	"\fPAYLOAD_TEXT\x10\x01*Z\n" +
+	"\x14IntervalDistribution\x12\x12\n" +
+	"\x0eINTERVAL_FIXED\x10\x00\x12\x14\n" +
+	"\x10INTERVAL_UNIFORM\x10\x01\x12\x18\n" +
+	"\x14INTERVAL_EXPONENTIAL\x10\x022\xd6\x02\n" +
 	"\x10BenchmarkService\x127\n" +
 	"\x04Ping\x12\x16.benchmark.PingRequest\x1a\x17.benchmark.PingResponse\x12:\n" +
 	"\x05Stats\x12\x17.benchmark.StatsRequest\x1a\x18.benchmark.StatsResponse\x12A\n" +
@@ -230,29 +451,36 @@ func file_proto_benchmark_proto_rawDescGZIP() []byte {
 	return file_proto_benchmark_proto_rawDescData
 }
 
-var file_proto_benchmark_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_benchmark_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_proto_benchmark_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_proto_benchmark_proto_goTypes = []any{
-	(*PingRequest)(nil),   // 0: benchmark.PingRequest
-	(*PingResponse)(nil),  // 1: benchmark.PingResponse
-	(*StatsRequest)(nil),  // 2: benchmark.StatsRequest
-	(*StatsResponse)(nil), // 3: benchmark.StatsResponse
+	(PayloadFill)(0),          // 0: benchmark.PayloadFill
+	(IntervalDistribution)(0), // 1: benchmark.IntervalDistribution
+	(*PingRequest)(nil),       // 2: benchmark.PingRequest
+	(*StreamParams)(nil),      // 3: benchmark.StreamParams
+	(*PingResponse)(nil),      // 4: benchmark.PingResponse
+	(*StatsRequest)(nil),      // 5: benchmark.StatsRequest
+	(*StatsResponse)(nil),     // 6: benchmark.StatsResponse
 }
 var file_proto_benchmark_proto_depIdxs = []int32{
-	0, // 0: benchmark.BenchmarkService.Ping:input_type -> benchmark.PingRequest
-	2, // 1: benchmark.BenchmarkService.Stats:input_type -> benchmark.StatsRequest
-	0, // 2: benchmark.BenchmarkService.StreamPing:input_type -> benchmark.PingRequest
-	0, // 3: benchmark.BenchmarkService.PushNotifications:input_type -> benchmark.PingRequest
-	0, // 4: benchmark.BenchmarkService.AggregatePing:input_type -> benchmark.PingRequest
-	1, // 5: benchmark.BenchmarkService.Ping:output_type -> benchmark.PingResponse
-	3, // 6: benchmark.BenchmarkService.Stats:output_type -> benchmark.StatsResponse
-	1, // 7: benchmark.BenchmarkService.StreamPing:output_type -> benchmark.PingResponse
-	1, // 8: benchmark.BenchmarkService.PushNotifications:output_type -> benchmark.PingResponse
-	1, // 9: benchmark.BenchmarkService.AggregatePing:output_type -> benchmark.PingResponse
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	3, // 0: benchmark.PingRequest.stream:type_name -> benchmark.StreamParams
+	0, // 1: benchmark.PingRequest.response_fill:type_name -> benchmark.PayloadFill
+	1, // 2: benchmark.StreamParams.distribution:type_name -> benchmark.IntervalDistribution
+	2, // 3: benchmark.BenchmarkService.Ping:input_type -> benchmark.PingRequest
+	5, // 4: benchmark.BenchmarkService.Stats:input_type -> benchmark.StatsRequest
+	2, // 5: benchmark.BenchmarkService.StreamPing:input_type -> benchmark.PingRequest
+	2, // 6: benchmark.BenchmarkService.PushNotifications:input_type -> benchmark.PingRequest
+	2, // 7: benchmark.BenchmarkService.AggregatePing:input_type -> benchmark.PingRequest
+	4, // 8: benchmark.BenchmarkService.Ping:output_type -> benchmark.PingResponse
+	6, // 9: benchmark.BenchmarkService.Stats:output_type -> benchmark.StatsResponse
+	4, // 10: benchmark.BenchmarkService.StreamPing:output_type -> benchmark.PingResponse
+	4, // 11: benchmark.BenchmarkService.PushNotifications:output_type -> benchmark.PingResponse
+	4, // 12: benchmark.BenchmarkService.AggregatePing:output_type -> benchmark.PingResponse
+	8, // [8:13] is the sub-list for method output_type
+	3, // [3:8] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_proto_benchmark_proto_init() }
@@ -265,13 +493,14 @@ func file_proto_benchmark_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_benchmark_proto_rawDesc), len(file_proto_benchmark_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      2,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_benchmark_proto_goTypes,
 		DependencyIndexes: file_proto_benchmark_proto_depIdxs,
+		EnumInfos:         file_proto_benchmark_proto_enumTypes,
 		MessageInfos:      file_proto_benchmark_proto_msgTypes,
 	}.Build()
 	File_proto_benchmark_proto = out.File
@@ -0,0 +1,751 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v3.21.12
+// source: proto/coordinator.proto
+
+package benchmark
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AgentMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*AgentMessage_Hello
+	//	*AgentMessage_Interval
+	//	*AgentMessage_Result
+	//	*AgentMessage_Clock
+	Body          isAgentMessage_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentMessage) Reset() {
+	*x = AgentMessage{}
+	mi := &file_proto_coordinator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentMessage) ProtoMessage() {}
+
+func (x *AgentMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_coordinator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentMessage.ProtoReflect.Descriptor instead.
+func (*AgentMessage) Descriptor() ([]byte, []int) {
+	return file_proto_coordinator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AgentMessage) GetBody() isAgentMessage_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetHello() *AgentHello {
+	if x != nil {
+		if x, ok := x.Body.(*AgentMessage_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetInterval() *IntervalReport {
+	if x != nil {
+		if x, ok := x.Body.(*AgentMessage_Interval); ok {
+			return x.Interval
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetResult() *TaskResult {
+	if x != nil {
+		if x, ok := x.Body.(*AgentMessage_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetClock() *ClockReply {
+	if x != nil {
+		if x, ok := x.Body.(*AgentMessage_Clock); ok {
+			return x.Clock
+		}
+	}
+	return nil
+}
+
+type isAgentMessage_Body interface {
+	isAgentMessage_Body()
+}
+
+type AgentMessage_Hello struct {
+	Hello *AgentHello `protobuf:"bytes,1,opt,name=hello,proto3,oneof"`
+}
+
+type AgentMessage_Interval struct {
+	Interval *IntervalReport `protobuf:"bytes,2,opt,name=interval,proto3,oneof"`
+}
+
+type AgentMessage_Result struct {
+	Result *TaskResult `protobuf:"bytes,3,opt,name=result,proto3,oneof"`
+}
+
+type AgentMessage_Clock struct {
+	Clock *ClockReply `protobuf:"bytes,4,opt,name=clock,proto3,oneof"`
+}
+
+func (*AgentMessage_Hello) isAgentMessage_Body() {}
+
+func (*AgentMessage_Interval) isAgentMessage_Body() {}
+
+func (*AgentMessage_Result) isAgentMessage_Body() {}
+
+func (*AgentMessage_Clock) isAgentMessage_Body() {}
+
+type AgentHello struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentHello) Reset() {
+	*x = AgentHello{}
+	mi := &file_proto_coordinator_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentHello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentHello) ProtoMessage() {}
+
+func (x *AgentHello) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_coordinator_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentHello.ProtoReflect.Descriptor instead.
+func (*AgentHello) Descriptor() ([]byte, []int) {
+	return file_proto_coordinator_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AgentHello) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ClockReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProbeUnixNano int64                  `protobuf:"varint,1,opt,name=probe_unix_nano,json=probeUnixNano,proto3" json:"probe_unix_nano,omitempty"`
+	AgentUnixNano int64                  `protobuf:"varint,2,opt,name=agent_unix_nano,json=agentUnixNano,proto3" json:"agent_unix_nano,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClockReply) Reset() {
+	*x = ClockReply{}
+	mi := &file_proto_coordinator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClockReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClockReply) ProtoMessage() {}
+
+func (x *ClockReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_coordinator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClockReply.ProtoReflect.Descriptor instead.
+func (*ClockReply) Descriptor() ([]byte, []int) {
+	return file_proto_coordinator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ClockReply) GetProbeUnixNano() int64 {
+	if x != nil {
+		return x.ProbeUnixNano
+	}
+	return 0
+}
+
+func (x *ClockReply) GetAgentUnixNano() int64 {
+	if x != nil {
+		return x.AgentUnixNano
+	}
+	return 0
+}
+
+type CoordinatorMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*CoordinatorMessage_Task
+	//	*CoordinatorMessage_Done
+	//	*CoordinatorMessage_Clock
+	Body          isCoordinatorMessage_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CoordinatorMessage) Reset() {
+	*x = CoordinatorMessage{}
+	mi := &file_proto_coordinator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CoordinatorMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CoordinatorMessage) ProtoMessage() {}
+
+func (x *CoordinatorMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_coordinator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CoordinatorMessage.ProtoReflect.Descriptor instead.
+func (*CoordinatorMessage) Descriptor() ([]byte, []int) {
+	return file_proto_coordinator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CoordinatorMessage) GetBody() isCoordinatorMessage_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *CoordinatorMessage) GetTask() *AgentTask {
+	if x != nil {
+		if x, ok := x.Body.(*CoordinatorMessage_Task); ok {
+			return x.Task
+		}
+	}
+	return nil
+}
+
+func (x *CoordinatorMessage) GetDone() bool {
+	if x != nil {
+		if x, ok := x.Body.(*CoordinatorMessage_Done); ok {
+			return x.Done
+		}
+	}
+	return false
+}
+
+func (x *CoordinatorMessage) GetClock() *ClockProbe {
+	if x != nil {
+		if x, ok := x.Body.(*CoordinatorMessage_Clock); ok {
+			return x.Clock
+		}
+	}
+	return nil
+}
+
+type isCoordinatorMessage_Body interface {
+	isCoordinatorMessage_Body()
+}
+
+type CoordinatorMessage_Task struct {
+	Task *AgentTask `protobuf:"bytes,1,opt,name=task,proto3,oneof"`
+}
+
+type CoordinatorMessage_Done struct {
+	Done bool `protobuf:"varint,2,opt,name=done,proto3,oneof"`
+}
+
+type CoordinatorMessage_Clock struct {
+	Clock *ClockProbe `protobuf:"bytes,3,opt,name=clock,proto3,oneof"`
+}
+
+func (*CoordinatorMessage_Task) isCoordinatorMessage_Body() {}
+
+func (*CoordinatorMessage_Done) isCoordinatorMessage_Body() {}
+
+func (*CoordinatorMessage_Clock) isCoordinatorMessage_Body() {}
+
+type ClockProbe struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UnixNano      int64                  `protobuf:"varint,1,opt,name=unix_nano,json=unixNano,proto3" json:"unix_nano,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClockProbe) Reset() {
+	*x = ClockProbe{}
+	mi := &file_proto_coordinator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClockProbe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClockProbe) ProtoMessage() {}
+
+func (x *ClockProbe) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_coordinator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClockProbe.ProtoReflect.Descriptor instead.
+func (*ClockProbe) Descriptor() ([]byte, []int) {
+	return file_proto_coordinator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ClockProbe) GetUnixNano() int64 {
+	if x != nil {
+		return x.UnixNano
+	}
+	return 0
+}
+
+type AgentTask struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Index         uint32                 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Agents        uint32                 `protobuf:"varint,3,opt,name=agents,proto3" json:"agents,omitempty"`
+	Target        string                 `protobuf:"bytes,4,opt,name=target,proto3" json:"target,omitempty"`
+	Stage         []byte                 `protobuf:"bytes,5,opt,name=stage,proto3" json:"stage,omitempty"`
+	StartUnixNano int64                  `protobuf:"varint,6,opt,name=start_unix_nano,json=startUnixNano,proto3" json:"start_unix_nano,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentTask) Reset() {
+	*x = AgentTask{}
+	mi := &file_proto_coordinator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentTask) ProtoMessage() {}
+
+func (x *AgentTask) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_coordinator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentTask.ProtoReflect.Descriptor instead.
+func (*AgentTask) Descriptor() ([]byte, []int) {
+	return file_proto_coordinator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AgentTask) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AgentTask) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *AgentTask) GetAgents() uint32 {
+	if x != nil {
+		return x.Agents
+	}
+	return 0
+}
+
+func (x *AgentTask) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *AgentTask) GetStage() []byte {
+	if x != nil {
+		return x.Stage
+	}
+	return nil
+}
+
+func (x *AgentTask) GetStartUnixNano() int64 {
+	if x != nil {
+		return x.StartUnixNano
+	}
+	return 0
+}
+
+type IntervalReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Task          uint32                 `protobuf:"varint,1,opt,name=task,proto3" json:"task,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	OffsetNs      int64                  `protobuf:"varint,3,opt,name=offset_ns,json=offsetNs,proto3" json:"offset_ns,omitempty"`
+	LengthNs      int64                  `protobuf:"varint,4,opt,name=length_ns,json=lengthNs,proto3" json:"length_ns,omitempty"`
+	Success       int64                  `protobuf:"varint,5,opt,name=success,proto3" json:"success,omitempty"`
+	Failures      int64                  `protobuf:"varint,6,opt,name=failures,proto3" json:"failures,omitempty"`
+	Discarded     int64                  `protobuf:"varint,7,opt,name=discarded,proto3" json:"discarded,omitempty"`
+	Latency       []byte                 `protobuf:"bytes,8,opt,name=latency,proto3" json:"latency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IntervalReport) Reset() {
+	*x = IntervalReport{}
+	mi := &file_proto_coordinator_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IntervalReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IntervalReport) ProtoMessage() {}
+
+func (x *IntervalReport) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_coordinator_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IntervalReport.ProtoReflect.Descriptor instead.
+func (*IntervalReport) Descriptor() ([]byte, []int) {
+	return file_proto_coordinator_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *IntervalReport) GetTask() uint32 {
+	if x != nil {
+		return x.Task
+	}
+	return 0
+}
+
+func (x *IntervalReport) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *IntervalReport) GetOffsetNs() int64 {
+	if x != nil {
+		return x.OffsetNs
+	}
+	return 0
+}
+
+func (x *IntervalReport) GetLengthNs() int64 {
+	if x != nil {
+		return x.LengthNs
+	}
+	return 0
+}
+
+func (x *IntervalReport) GetSuccess() int64 {
+	if x != nil {
+		return x.Success
+	}
+	return 0
+}
+
+func (x *IntervalReport) GetFailures() int64 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *IntervalReport) GetDiscarded() int64 {
+	if x != nil {
+		return x.Discarded
+	}
+	return 0
+}
+
+func (x *IntervalReport) GetLatency() []byte {
+	if x != nil {
+		return x.Latency
+	}
+	return nil
+}
+
+type TaskResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Task          uint32                 `protobuf:"varint,1,opt,name=task,proto3" json:"task,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Results       []byte                 `protobuf:"bytes,3,opt,name=results,proto3" json:"results,omitempty"`
+	Metrics       []byte                 `protobuf:"bytes,4,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskResult) Reset() {
+	*x = TaskResult{}
+	mi := &file_proto_coordinator_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskResult) ProtoMessage() {}
+
+func (x *TaskResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_coordinator_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskResult.ProtoReflect.Descriptor instead.
+func (*TaskResult) Descriptor() ([]byte, []int) {
+	return file_proto_coordinator_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TaskResult) GetTask() uint32 {
+	if x != nil {
+		return x.Task
+	}
+	return 0
+}
+
+func (x *TaskResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *TaskResult) GetResults() []byte {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *TaskResult) GetMetrics() []byte {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+var File_proto_coordinator_proto protoreflect.FileDescriptor
+
+const file_proto_coordinator_proto_rawDesc = "" +
+	"\n" +
+	"\x17proto/coordinator.proto\x12\tbenchmark\"\xde\x01\n" +
+	"\fAgentMessage\x12-\n" +
+	"\x05hello\x18\x01 \x01(\v2\x15.benchmark.AgentHelloH\x00R\x05hello\x127\n" +
+	"\binterval\x18\x02 \x01(\v2\x19.benchmark.IntervalReportH\x00R\binterval\x12/\n" +
+	"\x06result\x18\x03 \x01(\v2\x15.benchmark.TaskResultH\x00R\x06result\x12-\n" +
+	"\x05clock\x18\x04 \x01(\v2\x15.benchmark.ClockReplyH\x00R\x05clockB\x06\n" +
+	"\x04body\"&\n" +
+	"\n" +
+	"AgentHello\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04nameJ\x04\b\x02\x10\x03\"\\\n" +
+	"\n" +
+	"ClockReply\x12&\n" +
+	"\x0fprobe_unix_nano\x18\x01 \x01(\x03R\rprobeUnixNano\x12&\n" +
+	"\x0fagent_unix_nano\x18\x02 \x01(\x03R\ragentUnixNano\"\x8d\x01\n" +
+	"\x12CoordinatorMessage\x12*\n" +
+	"\x04task\x18\x01 \x01(\v2\x14.benchmark.AgentTaskH\x00R\x04task\x12\x14\n" +
+	"\x04done\x18\x02 \x01(\bH\x00R\x04done\x12-\n" +
+	"\x05clock\x18\x03 \x01(\v2\x15.benchmark.ClockProbeH\x00R\x05clockB\x06\n" +
+	"\x04body\")\n" +
+	"\n" +
+	"ClockProbe\x12\x1b\n" +
+	"\tunix_nano\x18\x01 \x01(\x03R\bunixNano\"\x9f\x01\n" +
+	"\tAgentTask\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\rR\x02id\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\rR\x05index\x12\x16\n" +
+	"\x06agents\x18\x03 \x01(\rR\x06agents\x12\x16\n" +
+	"\x06target\x18\x04 \x01(\tR\x06target\x12\x14\n" +
+	"\x05stage\x18\x05 \x01(\fR\x05stage\x12&\n" +
+	"\x0fstart_unix_nano\x18\x06 \x01(\x03R\rstartUnixNano\"\xe0\x01\n" +
+	"\x0eIntervalReport\x12\x12\n" +
+	"\x04task\x18\x01 \x01(\rR\x04task\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1b\n" +
+	"\toffset_ns\x18\x03 \x01(\x03R\boffsetNs\x12\x1b\n" +
+	"\tlength_ns\x18\x04 \x01(\x03R\blengthNs\x12\x18\n" +
+	"\asuccess\x18\x05 \x01(\x03R\asuccess\x12\x1a\n" +
+	"\bfailures\x18\x06 \x01(\x03R\bfailures\x12\x1c\n" +
+	"\tdiscarded\x18\a \x01(\x03R\tdiscarded\x12\x18\n" +
+	"\alatency\x18\b \x01(\fR\alatency\"j\n" +
+	"\n" +
+	"TaskResult\x12\x12\n" +
+	"\x04task\x18\x01 \x01(\rR\x04task\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x18\n" +
+	"\aresults\x18\x03 \x01(\fR\aresults\x12\x18\n" +
+	"\ametrics\x18\x04 \x01(\fR\ametrics2X\n" +
+	"\x12CoordinatorService\x12B\n" +
+	"\x04Join\x12\x17.benchmark.AgentMessage\x1a\x1d.benchmark.CoordinatorMessage(\x010\x01B;Z9github.com/go-portfolio/go-grpc-benchmark/proto;benchmarkb\x06proto3"
+
+var (
+	file_proto_coordinator_proto_rawDescOnce sync.Once
+	file_proto_coordinator_proto_rawDescData []byte
+)
+
+func file_proto_coordinator_proto_rawDescGZIP() []byte {
+	file_proto_coordinator_proto_rawDescOnce.Do(func() {
+		file_proto_coordinator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_coordinator_proto_rawDesc), len(file_proto_coordinator_proto_rawDesc)))
+	})
+	return file_proto_coordinator_proto_rawDescData
+}
+
+var file_proto_coordinator_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_coordinator_proto_goTypes = []any{
+	(*AgentMessage)(nil),       // 0: benchmark.AgentMessage
+	(*AgentHello)(nil),         // 1: benchmark.AgentHello
+	(*ClockReply)(nil),         // 2: benchmark.ClockReply
+	(*CoordinatorMessage)(nil), // 3: benchmark.CoordinatorMessage
+	(*ClockProbe)(nil),         // 4: benchmark.ClockProbe
+	(*AgentTask)(nil),          // 5: benchmark.AgentTask
+	(*IntervalReport)(nil),     // 6: benchmark.IntervalReport
+	(*TaskResult)(nil),         // 7: benchmark.TaskResult
+}
+var file_proto_coordinator_proto_depIdxs = []int32{
+	1, // 0: benchmark.AgentMessage.hello:type_name -> benchmark.AgentHello
+	6, // 1: benchmark.AgentMessage.interval:type_name -> benchmark.IntervalReport
+	7, // 2: benchmark.AgentMessage.result:type_name -> benchmark.TaskResult
+	2, // 3: benchmark.AgentMessage.clock:type_name -> benchmark.ClockReply
+	5, // 4: benchmark.CoordinatorMessage.task:type_name -> benchmark.AgentTask
+	4, // 5: benchmark.CoordinatorMessage.clock:type_name -> benchmark.ClockProbe
+	0, // 6: benchmark.CoordinatorService.Join:input_type -> benchmark.AgentMessage
+	3, // 7: benchmark.CoordinatorService.Join:output_type -> benchmark.CoordinatorMessage
+	7, // [7:8] is the sub-list for method output_type
+	6, // [6:7] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_proto_coordinator_proto_init() }
+func file_proto_coordinator_proto_init() {
+	if File_proto_coordinator_proto != nil {
+		return
+	}
+	file_proto_coordinator_proto_msgTypes[0].OneofWrappers = []any{
+		(*AgentMessage_Hello)(nil),
+		(*AgentMessage_Interval)(nil),
+		(*AgentMessage_Result)(nil),
+		(*AgentMessage_Clock)(nil),
+	}
+	file_proto_coordinator_proto_msgTypes[3].OneofWrappers = []any{
+		(*CoordinatorMessage_Task)(nil),
+		(*CoordinatorMessage_Done)(nil),
+		(*CoordinatorMessage_Clock)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_coordinator_proto_rawDesc), len(file_proto_coordinator_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_coordinator_proto_goTypes,
+		DependencyIndexes: file_proto_coordinator_proto_depIdxs,
+		MessageInfos:      file_proto_coordinator_proto_msgTypes,
+	}.Build()
+	File_proto_coordinator_proto = out.File
+	file_proto_coordinator_proto_goTypes = nil
+	file_proto_coordinator_proto_depIdxs = nil
+}